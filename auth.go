@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/akeyless-community/k8s-auth-validator/pkg/validator"
+	akeyless "github.com/akeylesslabs/akeyless-go/v2"
+)
+
+const ACCESS_TYPE_ACCESS_KEY = "access_key"
+const ACCESS_TYPE_JWT = "jwt"
+const ACCESS_TYPE_UNIVERSAL_IDENTITY = "universal_identity"
+
+// newAuthBody builds the Akeyless auth request for the access type, checking the credential it needs is set
+func newAuthBody(authOptions Options) (akeyless.Auth, error) {
+	if len(authOptions.AccessId) == 0 {
+		return akeyless.Auth{}, errors.New("Akeyless access ID is not set. Please set it using the --access-id flag or set the AKEYLESS_ACCESS_ID environment variable")
+	}
+
+	accessId := authOptions.AccessId
+	accessType := authOptions.AccessType
+	authBody := akeyless.Auth{
+		AccessId:   &accessId,
+		AccessType: &accessType,
+	}
+
+	switch accessType {
+	case ACCESS_TYPE_ACCESS_KEY:
+		if len(authOptions.AccessKey) == 0 {
+			return authBody, errors.New("Akeyless access key is not set. Please set it using the --access-key flag or set the AKEYLESS_ACCESS_KEY environment variable")
+		}
+		accessKey := authOptions.AccessKey
+		authBody.AccessKey = &accessKey
+	case ACCESS_TYPE_JWT:
+		if len(authOptions.Jwt) == 0 {
+			return authBody, errors.New("JWT is not set. Please set it using the --jwt flag or set the AKEYLESS_JWT environment variable")
+		}
+		jwt := authOptions.Jwt
+		authBody.Jwt = &jwt
+	case ACCESS_TYPE_UNIVERSAL_IDENTITY:
+		if len(authOptions.UidToken) == 0 {
+			return authBody, errors.New("Universal identity token is not set. Please set it using the --uid-token flag or set the AKEYLESS_UID_TOKEN environment variable")
+		}
+		uidToken := authOptions.UidToken
+		authBody.UidToken = &uidToken
+	default:
+		return authBody, fmt.Errorf("unsupported access type %q", accessType)
+	}
+
+	return authBody, nil
+}
+
+// authenticate exchanges the credentials of the auth request for an Akeyless token
+func authenticate(ctx context.Context, client *akeyless.V2ApiService, authBody akeyless.Auth) (string, error) {
+	authOutput, _, err := client.Auth(ctx).Body(authBody).Execute()
+	if err != nil {
+		return "", errors.New(validator.DescribeAkeylessError(err))
+	}
+	if len(authOutput.GetToken()) == 0 {
+		return "", errors.New("the Akeyless auth response does not contain a token")
+	}
+	return authOutput.GetToken(), nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	akeyless "github.com/akeylesslabs/akeyless-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAuthBody(t *testing.T) {
+	authBody, err := newAuthBody(Options{AccessId: "p-1234", AccessKey: "secret", AccessType: ACCESS_TYPE_ACCESS_KEY})
+	assert.NoError(t, err)
+	assert.Equal(t, "p-1234", authBody.GetAccessId())
+	assert.Equal(t, ACCESS_TYPE_ACCESS_KEY, authBody.GetAccessType())
+	assert.Equal(t, "secret", authBody.GetAccessKey())
+
+	authBody, err = newAuthBody(Options{AccessId: "p-1234", Jwt: "header.payload.signature", AccessType: ACCESS_TYPE_JWT})
+	assert.NoError(t, err)
+	assert.Equal(t, "header.payload.signature", authBody.GetJwt())
+	assert.False(t, authBody.HasAccessKey())
+
+	authBody, err = newAuthBody(Options{AccessId: "p-1234", UidToken: "u-token", AccessType: ACCESS_TYPE_UNIVERSAL_IDENTITY})
+	assert.NoError(t, err)
+	assert.Equal(t, "u-token", authBody.GetUidToken())
+
+	_, err = newAuthBody(Options{AccessKey: "secret", AccessType: ACCESS_TYPE_ACCESS_KEY})
+	assert.ErrorContains(t, err, "access ID is not set")
+
+	_, err = newAuthBody(Options{AccessId: "p-1234", AccessType: ACCESS_TYPE_ACCESS_KEY})
+	assert.ErrorContains(t, err, "access key is not set")
+
+	_, err = newAuthBody(Options{AccessId: "p-1234", AccessType: ACCESS_TYPE_JWT})
+	assert.ErrorContains(t, err, "--jwt")
+
+	_, err = newAuthBody(Options{AccessId: "p-1234", AccessType: "saml"})
+	assert.ErrorContains(t, err, "unsupported access type")
+}
+
+func TestAuthenticate(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		assert.Equal(t, "/auth", r.URL.Path)
+
+		var body akeyless.Auth
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.GetAccessKey() != "valid-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": "access denied"}`))
+			return
+		}
+		w.Write([]byte(`{"token": "t-123"}`))
+	}))
+	defer mockServer.Close()
+
+	client := newAkeylessClient(mockServer.URL)
+
+	authBody, err := newAuthBody(Options{AccessId: "p-1234", AccessKey: "valid-key", AccessType: ACCESS_TYPE_ACCESS_KEY})
+	assert.NoError(t, err)
+	token, err := authenticate(context.Background(), client, authBody)
+	assert.NoError(t, err)
+	assert.Equal(t, "t-123", token)
+
+	authBody, err = newAuthBody(Options{AccessId: "p-1234", AccessKey: "wrong-key", AccessType: ACCESS_TYPE_ACCESS_KEY})
+	assert.NoError(t, err)
+	_, err = authenticate(context.Background(), client, authBody)
+	assert.ErrorContains(t, err, "access denied")
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/akeyless-community/k8s-auth-validator/pkg/validator"
+	"github.com/logrusorgru/aurora/v4"
+)
+
+const BOOTSTRAP_COMMAND = "bootstrap"
+
+// BootstrapOptions are the options of the bootstrap command, the authentication, kubeconfig and --dry-run
+// options are shared with the validation
+type BootstrapOptions struct {
+	GatewayUrl             string `long:"gateway-url" description:"URL of the gateway to create the k8s auth config on" required:"true"`
+	Name                   string `long:"name" description:"Name of the new k8s auth config" required:"true"`
+	AuthMethodAccessId     string `long:"auth-method-access-id" description:"Access ID of the k8s auth method the config is created for" required:"true"`
+	SigningKey             string `long:"signing-key" description:"Private key of the k8s auth method, PEM or base64 encoded" required:"false"`
+	SigningKeyFile         string `long:"signing-key-file" description:"File holding the private key of the k8s auth method" required:"false"`
+	TokenExp               int64  `long:"token-exp" description:"Lifetime in seconds of the Akeyless tokens issued through the config" required:"false" default:"300"`
+	ReviewerNamespace      string `long:"reviewer-namespace" description:"Namespace of the token reviewer service account" required:"false" default:"akeyless"`
+	ReviewerServiceAccount string `long:"reviewer-service-account" description:"Name of the token reviewer service account" required:"false" default:"gateway-token-reviewer"`
+}
+
+var bootstrapOptions BootstrapOptions
+
+// readSigningKey returns the auth method private key of --signing-key or --signing-key-file
+func readSigningKey(bootstrapOptions BootstrapOptions) (string, error) {
+	if len(bootstrapOptions.SigningKey) > 0 && len(bootstrapOptions.SigningKeyFile) > 0 {
+		return "", fmt.Errorf("the --signing-key flag cannot be combined with the --signing-key-file flag")
+	}
+	if len(bootstrapOptions.SigningKeyFile) == 0 {
+		return bootstrapOptions.SigningKey, nil
+	}
+	signingKey, err := os.ReadFile(bootstrapOptions.SigningKeyFile)
+	if err != nil {
+		return "", fmt.Errorf("unable to read the signing key file: %w", err)
+	}
+	return string(signingKey), nil
+}
+
+// printBootstrapPlan prints the manifests of the token reviewer and the gateway request creating the config
+func printBootstrapPlan(ctx context.Context, k8sAuthValidator *validator.Validator, bootstrap validator.Bootstrap) error {
+	manifests, err := validator.ReviewerManifests(bootstrap.Namespace, bootstrap.ServiceAccount)
+	if err != nil {
+		return err
+	}
+	request, err := k8sAuthValidator.BootstrapRequest(ctx, bootstrap)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "# Token reviewer manifests, apply them with:", "kubectl apply -f -")
+	fmt.Fprint(out, manifests)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "# Request to", bootstrap.GatewayUrl+validator.GATEWAY_API_PATH+"/gateway-create-k8s-auth-config")
+	fmt.Fprintln(out, request)
+	return nil
+}
+
+// runBootstrap creates the token reviewer in the cluster of the kubeconfig context, or the in-cluster
+// cluster, and a k8s auth config for it on the gateway. With --dry-run nothing is created and the manifests
+// and gateway request are printed instead.
+func runBootstrap(ctx context.Context) {
+	if options.AllContexts || len(options.ContextRegex) > 0 {
+		printErrorMessages("", "The bootstrap command creates a single config, it cannot be combined with the --all-contexts or --context-regex flags")
+		mightExit(true, EXIT_CODE_ERROR)
+	}
+	if options.Fix || options.Yes || options.Watch {
+		printErrorMessages("", "The bootstrap command cannot be combined with the --fix, --yes or --watch flags")
+		mightExit(true, EXIT_CODE_ERROR)
+	}
+
+	signingKey, err := readSigningKey(bootstrapOptions)
+	if err != nil {
+		printErrorMessages("", err.Error())
+		mightExit(true, EXIT_CODE_ERROR)
+	}
+	if len(signingKey) == 0 && !options.DryRun {
+		printErrorMessages("", "The private key of the auth method is not set. Please set it using the --signing-key or --signing-key-file flag")
+		mightExit(true, EXIT_CODE_ERROR)
+	}
+
+	// the token is only needed to create the config on the gateway
+	if len(options.Token) == 0 && len(options.AccessId) == 0 && !options.DryRun {
+		printErrorMessages("", "Akeyless token is not set. Please set the token using the -t or --token flag or set the AKEYLESS_TOKEN environment variable, or authenticate with the --access-id flag")
+		mightExit(true, EXIT_CODE_AKEYLESS_ERROR)
+	}
+	if len(options.Token) > 0 && len(options.AccessId) > 0 {
+		printErrorMessages("", "The --token flag cannot be combined with the --access-id flag")
+		mightExit(true, EXIT_CODE_ERROR)
+	}
+
+	clusterDetails := selectClusterTargets(false)[0]
+
+	client := newAkeylessClient(options.ApiGatewayUrl)
+	if len(options.Token) == 0 && len(options.AccessId) > 0 && !options.DryRun {
+		options.Token = retrieveTokenUsingAccessId(client)
+	}
+	k8sAuthValidator := newValidator(client)
+
+	bootstrap := validator.Bootstrap{
+		Cluster:            clusterDetails,
+		GatewayUrl:         bootstrapOptions.GatewayUrl,
+		Name:               bootstrapOptions.Name,
+		AuthMethodAccessID: bootstrapOptions.AuthMethodAccessId,
+		SigningKey:         signingKey,
+		TokenExpiration:    bootstrapOptions.TokenExp,
+		Namespace:          bootstrapOptions.ReviewerNamespace,
+		ServiceAccount:     bootstrapOptions.ReviewerServiceAccount,
+	}
+
+	if options.DryRun {
+		if err := printBootstrapPlan(ctx, k8sAuthValidator, bootstrap); err != nil {
+			printErrorMessages(err.Error(), "Unable to print the bootstrap manifests:")
+			mightExit(true, EXIT_CODE_ERROR)
+		}
+		return
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Creating token reviewer:", aurora.BrightCyan(bootstrap.Namespace+"/"+bootstrap.ServiceAccount))
+	if err := k8sAuthValidator.RunBootstrap(ctx, bootstrap); err != nil {
+		printErrorMessages(err.Error(), "Unable to bootstrap the k8s auth config:")
+		mightExit(true, EXIT_CODE_ERROR)
+	}
+	fmt.Fprintln(out, "Created K8S Auth Config:", aurora.BrightGreen(bootstrap.Name), "on gateway", aurora.BrightGreen(bootstrap.GatewayUrl), "run the validator to confirm")
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/akeyless-community/k8s-auth-validator/pkg/validator"
+)
+
+// splitCheckIDs splits the comma separated check IDs of the repeatable --checks and --skip-checks flags
+func splitCheckIDs(values []string) []string {
+	var ids []string
+	for _, value := range values {
+		for _, id := range strings.Split(value, ",") {
+			if id = strings.TrimSpace(id); len(id) > 0 {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// selectChecks returns the built-in checks selected with the --checks and --skip-checks flags
+func selectChecks(checkOptions Options) ([]validator.Check, error) {
+	return validator.SelectChecks(validator.BuiltinChecks(), splitCheckIDs(checkOptions.Checks), splitCheckIDs(checkOptions.SkipChecks))
+}
+
+// printCheckList prints the ID, severity and description of the checks in the order they run
+func printCheckList(writer io.Writer, checks []validator.Check) {
+	tableWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tableWriter, "CHECK\tSEVERITY\tDESCRIPTION")
+	for _, check := range checks {
+		fmt.Fprintf(tableWriter, "%s\t%s\t%s\n", check.ID(), check.Severity(), check.Description())
+	}
+	tableWriter.Flush()
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/akeyless-community/k8s-auth-validator/pkg/validator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectChecks(t *testing.T) {
+	checks, err := selectChecks(Options{SkipChecks: []string{"token-reviewer,reviewer-rbac"}})
+	assert.NoError(t, err)
+	assert.NotContains(t, validator.CheckIDs(checks), validator.CHECK_TOKEN_REVIEWER)
+	assert.NotContains(t, validator.CheckIDs(checks), validator.CHECK_REVIEWER_RBAC)
+	assert.Contains(t, validator.CheckIDs(checks), validator.CHECK_CA_CERT)
+
+	checks, err = selectChecks(Options{Checks: []string{"tls-verify", " ca-cert"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{validator.CHECK_CA_CERT, validator.CHECK_TLS}, validator.CheckIDs(checks))
+
+	_, err = selectChecks(Options{Checks: []string{"ca-certs"}})
+	assert.ErrorContains(t, err, "unknown check(s) ca-certs")
+}
+
+func TestPrintCheckList(t *testing.T) {
+	var output bytes.Buffer
+	printCheckList(&output, validator.BuiltinChecks())
+	assert.Contains(t, output.String(), "CHECK")
+	assert.Regexp(t, `token-reviewer\s+error\s+The token reviewer JWT is accepted`, output.String())
+}
@@ -0,0 +1,69 @@
+package main
+
+import "github.com/akeyless-community/k8s-auth-validator/pkg/validator"
+
+const FAIL_ON_ERROR = "error"
+const FAIL_ON_WARNING = "warning"
+
+// failsThreshold reports whether the check status fails the --fail-on threshold
+func failsThreshold(status validator.CheckStatus, failOn string) bool {
+	switch status {
+	case validator.CHECK_STATUS_FAIL:
+		return true
+	case validator.CHECK_STATUS_WARN:
+		return failOn == FAIL_ON_WARNING
+	default:
+		return false
+	}
+}
+
+// gatewayFetchFailed reports whether the k8s auth configs could not be fetched, either because none of the
+// gateways looked up could be read or because a gateway rejected the token
+func gatewayFetchFailed(gatewayReports []validator.GatewayReport) bool {
+	lookedUp, failed := 0, 0
+	for _, gatewayReport := range gatewayReports {
+		if gatewayReport.FetchErr != nil {
+			if validator.IsGatewayAuthError(gatewayReport.FetchErr) {
+				return true
+			}
+			failed++
+		}
+		if gatewayReport.Considered || gatewayReport.FetchErr != nil {
+			lookedUp++
+		}
+	}
+	return lookedUp > 0 && failed == lookedUp
+}
+
+// validationExitCode returns the exit code reflecting the outcome of the validations. Failing to fetch the k8s
+// auth configs from the gateways takes precedence, otherwise a cluster without any matching k8s auth config
+// takes precedence over failed checks because nothing could be validated for it.
+func validationExitCode(gatewayReports []validator.GatewayReport, clusterValidations []ClusterValidation, failOn string) int {
+	if gatewayFetchFailed(gatewayReports) {
+		return EXIT_CODE_AKEYLESS_ERROR
+	}
+
+	exitCode := EXIT_CODE_SUCCESS
+	for _, clusterValidation := range clusterValidations {
+		if len(clusterValidation.Findings) == 0 {
+			return EXIT_CODE_NO_MATCHING_CONFIG
+		}
+		for _, finding := range clusterValidation.Findings {
+			for _, check := range finding.Checks {
+				if failsThreshold(check.Status, failOn) {
+					exitCode = EXIT_CODE_CHECKS_FAILED
+				}
+			}
+		}
+	}
+	return exitCode
+}
+
+// fixExitCode returns the exit code of the validation made before the fixes, falling back to EXIT_CODE_ERROR
+// when the validation succeeded but a fix could not be planned or applied
+func fixExitCode(validationExitCode int, fixFailures int) int {
+	if validationExitCode == EXIT_CODE_SUCCESS && fixFailures > 0 {
+		return EXIT_CODE_ERROR
+	}
+	return validationExitCode
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/akeyless-community/k8s-auth-validator/pkg/validator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidationExitCode(t *testing.T) {
+	passing := validator.Finding{Checks: []validator.CheckResult{
+		{Name: validator.CHECK_CA_CERT, Status: validator.CHECK_STATUS_PASS, Message: "CA Cert matches"},
+	}}
+
+	warning := validator.Finding{Checks: []validator.CheckResult{
+		{Name: validator.CHECK_CA_CERT, Status: validator.CHECK_STATUS_PASS, Message: "CA Cert matches"},
+		{Name: validator.CHECK_REVIEWER_JWT, Status: validator.CHECK_STATUS_WARN, Message: "expires soon"},
+	}}
+
+	failing := validator.Finding{Checks: []validator.CheckResult{
+		{Name: validator.CHECK_TOKEN_REVIEWER, Status: validator.CHECK_STATUS_FAIL, Message: "Token Reviewer JWT Access is not valid"},
+	}}
+
+	tests := []struct {
+		name               string
+		clusterValidations []ClusterValidation
+		failOn             string
+		expected           int
+	}{
+		{"all checks pass", []ClusterValidation{{Findings: []validator.Finding{passing}}}, FAIL_ON_ERROR, EXIT_CODE_SUCCESS},
+		{"warnings pass by default", []ClusterValidation{{Findings: []validator.Finding{warning}}}, FAIL_ON_ERROR, EXIT_CODE_SUCCESS},
+		{"warnings fail with fail-on warning", []ClusterValidation{{Findings: []validator.Finding{warning}}}, FAIL_ON_WARNING, EXIT_CODE_CHECKS_FAILED},
+		{"failed check", []ClusterValidation{{Findings: []validator.Finding{passing, failing}}}, FAIL_ON_ERROR, EXIT_CODE_CHECKS_FAILED},
+		{"no matching config", []ClusterValidation{{}}, FAIL_ON_ERROR, EXIT_CODE_NO_MATCHING_CONFIG},
+		{"no matching config wins over failed checks", []ClusterValidation{{Findings: []validator.Finding{failing}}, {}}, FAIL_ON_ERROR, EXIT_CODE_NO_MATCHING_CONFIG},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, validationExitCode(nil, test.clusterValidations, test.failOn))
+		})
+	}
+}
+
+func TestValidationExitCodeGatewayFetchFailed(t *testing.T) {
+	rejected := validator.GatewayReport{Name: "gw-rejected", FetchErr: &validator.GatewayStatusError{StatusCode: http.StatusForbidden, Status: "403 Forbidden"}}
+	unreachable := validator.GatewayReport{Name: "gw-down", FetchErr: errors.New("connection refused")}
+	considered := validator.GatewayReport{Name: "gw-prod", Considered: true}
+	skipped := validator.GatewayReport{Name: "gw-stopped", SkipReason: "gateway status is not Running"}
+	noMatchingConfig := []ClusterValidation{{}}
+
+	tests := []struct {
+		name           string
+		gatewayReports []validator.GatewayReport
+		expected       int
+	}{
+		{"token rejected by every gateway", []validator.GatewayReport{rejected, skipped}, EXIT_CODE_AKEYLESS_ERROR},
+		{"token rejected by one gateway", []validator.GatewayReport{rejected, considered}, EXIT_CODE_AKEYLESS_ERROR},
+		{"no gateway could be read", []validator.GatewayReport{unreachable, skipped}, EXIT_CODE_AKEYLESS_ERROR},
+		{"some gateways could be read", []validator.GatewayReport{unreachable, considered}, EXIT_CODE_NO_MATCHING_CONFIG},
+		{"every gateway skipped", []validator.GatewayReport{skipped}, EXIT_CODE_NO_MATCHING_CONFIG},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, validationExitCode(test.gatewayReports, noMatchingConfig, FAIL_ON_ERROR))
+		})
+	}
+}
+
+func TestFixExitCode(t *testing.T) {
+	assert.Equal(t, EXIT_CODE_SUCCESS, fixExitCode(EXIT_CODE_SUCCESS, 0))
+	assert.Equal(t, EXIT_CODE_ERROR, fixExitCode(EXIT_CODE_SUCCESS, 1))
+	// a failed fix does not hide the outcome of the validation
+	assert.Equal(t, EXIT_CODE_CHECKS_FAILED, fixExitCode(EXIT_CODE_CHECKS_FAILED, 1))
+	assert.Equal(t, EXIT_CODE_NO_MATCHING_CONFIG, fixExitCode(EXIT_CODE_NO_MATCHING_CONFIG, 2))
+	assert.Equal(t, EXIT_CODE_CHECKS_FAILED, fixExitCode(EXIT_CODE_CHECKS_FAILED, 0))
+}
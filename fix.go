@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/akeyless-community/k8s-auth-validator/pkg/validator"
+	"github.com/logrusorgru/aurora/v4"
+)
+
+// confirmInput is read to confirm every fix unless --yes is set
+var confirmInput io.Reader = os.Stdin
+
+// printConfigFix prints the field by field diff of the fix
+func printConfigFix(fix validator.ConfigFix) {
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Fix for K8S Auth Config:", aurora.BrightCyan(fix.GatewayName+"/"+fix.Current.Name), "matching context", aurora.BrightCyan(fix.Cluster.ContextName))
+	for _, change := range fix.Changes {
+		fmt.Fprintln(out, "  "+change.Field+":")
+		fmt.Fprintln(out, "    "+aurora.BrightRed("- "+change.Current).String())
+		fmt.Fprintln(out, "    "+aurora.BrightGreen("+ "+change.Fixed).String())
+	}
+}
+
+// confirmFix asks whether to apply the fix, anything but yes declines it
+func confirmFix(reader *bufio.Reader, fix validator.ConfigFix) bool {
+	fmt.Fprint(out, "Apply this fix to gateway ", fix.GatewayUrl, "? [y/N] ")
+	answer, _ := reader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// fixClusterValidations plans the fixes of the k8s auth configs of every validated cluster, prints their diff
+// and applies the confirmed ones. It returns the number of fixes that could not be planned or applied.
+func fixClusterValidations(ctx context.Context, k8sAuthValidator *validator.Validator, clusterValidations []ClusterValidation) int {
+	failures := 0
+	fixCount := 0
+	reader := bufio.NewReader(confirmInput)
+
+	for _, clusterValidation := range clusterValidations {
+		fixes, errs := k8sAuthValidator.PlanFixes(ctx, clusterValidation.Target, clusterValidation.Findings)
+		for _, err := range errs {
+			printErrorMessages(err.Error(), "Unable to plan a fix:")
+			failures++
+		}
+
+		for _, fix := range fixes {
+			fixCount++
+			printConfigFix(fix)
+
+			if options.DryRun {
+				continue
+			}
+			if !options.Yes && !confirmFix(reader, fix) {
+				fmt.Fprintln(out, "Skipped fix for K8S Auth Config:", aurora.BrightYellow(fix.Current.Name))
+				continue
+			}
+			if err := k8sAuthValidator.ApplyFix(ctx, fix); err != nil {
+				printErrorMessages(err.Error(), "Unable to apply the fix:")
+				failures++
+				continue
+			}
+			fmt.Fprintln(out, "Fixed K8S Auth Config:", aurora.BrightGreen(fix.Current.Name), "run the validator again to confirm")
+		}
+	}
+
+	if fixCount == 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "No K8S Auth Config to fix")
+	}
+	return failures
+}
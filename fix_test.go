@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/akeyless-community/k8s-auth-validator/pkg/validator"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestFixableClusterValidation() ClusterValidation {
+	clusterValidation := newTestClusterValidation(validator.CHECK_STATUS_PASS)
+	clusterValidation.Target.CertificateAuthorityData = []byte("cluster-ca")
+	clusterValidation.Findings[0].GatewayUrl = "https://gw.example.com"
+	clusterValidation.Findings[0].Checks[0].Status = validator.CHECK_STATUS_FAIL
+	return clusterValidation
+}
+
+func TestFixClusterValidations(t *testing.T) {
+	previousOut, previousInput, previousOptions := out, confirmInput, options
+	defer func() {
+		out, confirmInput, options = previousOut, previousInput, previousOptions
+	}()
+
+	t.Run("dry run", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		out = buffer
+		options.DryRun = true
+		options.Yes = false
+
+		failures := fixClusterValidations(context.Background(), validator.New(nil, "t-123"), []ClusterValidation{newTestFixableClusterValidation()})
+		assert.Equal(t, 0, failures)
+		assert.Contains(t, buffer.String(), "gw-prod/k8s-eks")
+		assert.Contains(t, buffer.String(), validator.FIELD_K8S_CA_CERT+":")
+		assert.NotContains(t, buffer.String(), "Apply this fix")
+	})
+
+	t.Run("declined", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		out = buffer
+		confirmInput = strings.NewReader("n\n")
+		options.DryRun = false
+		options.Yes = false
+
+		failures := fixClusterValidations(context.Background(), validator.New(nil, "t-123"), []ClusterValidation{newTestFixableClusterValidation()})
+		assert.Equal(t, 0, failures)
+		assert.Contains(t, buffer.String(), "Apply this fix to gateway https://gw.example.com? [y/N]")
+		assert.Contains(t, buffer.String(), "Skipped fix for K8S Auth Config:")
+	})
+
+	t.Run("nothing to fix", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		out = buffer
+
+		failures := fixClusterValidations(context.Background(), validator.New(nil, "t-123"), []ClusterValidation{newTestClusterValidation(validator.CHECK_STATUS_PASS)})
+		assert.Equal(t, 0, failures)
+		assert.Contains(t, buffer.String(), "No K8S Auth Config to fix")
+	})
+}
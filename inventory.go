@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/akeyless-community/k8s-auth-validator/pkg/validator"
+	"github.com/logrusorgru/aurora/v4"
+)
+
+// knownClusterTargets returns the clusters of every context of the kubeconfig, the configs matching none of
+// them are orphaned. Running in-cluster, only the validated cluster is known.
+func knownClusterTargets(validatedClusters []validator.ClusterTarget) []validator.ClusterTarget {
+	if options.InCluster {
+		return validatedClusters
+	}
+
+	config, err := validator.NewKubeconfigLoadingRules(options.Kubeconfig).Load()
+	if err != nil {
+		return validatedClusters
+	}
+	contextNames, err := validator.ListContextNames(config, "")
+	if err != nil {
+		return validatedClusters
+	}
+
+	var clusterTargets []validator.ClusterTarget
+	for _, contextName := range contextNames {
+		clusterTarget, err := validator.ResolveClusterTarget(config, contextName)
+		if err != nil {
+			if options.Verbose {
+				fmt.Fprintln(out, "Skipping context in the inventory:", aurora.BrightYellow(contextName), err)
+			}
+			continue
+		}
+		clusterTargets = append(clusterTargets, clusterTarget)
+	}
+	return clusterTargets
+}
+
+// buildInventory lists every k8s auth config of the loaded gateways against the validated and known clusters
+func buildInventory(ctx context.Context, k8sAuthValidator *validator.Validator, clusterValidations []ClusterValidation) []validator.InventoryEntry {
+	validatedClusters := make([]validator.ClusterTarget, 0, len(clusterValidations))
+	for _, clusterValidation := range clusterValidations {
+		validatedClusters = append(validatedClusters, clusterValidation.Target)
+	}
+	return k8sAuthValidator.Inventory(ctx, validatedClusters, knownClusterTargets(validatedClusters))
+}
+
+// inventoryStatus returns the colored status of the inventory entry
+func inventoryStatus(entry validator.InventoryEntry) aurora.Value {
+	switch entry.Status {
+	case validator.INVENTORY_MATCHES_CLUSTER:
+		return aurora.BrightGreen(entry.Status)
+	case validator.INVENTORY_MATCHES_CONTEXT:
+		return aurora.BrightCyan(entry.Status)
+	default:
+		return aurora.BrightRed(entry.Status)
+	}
+}
+
+// printInventory prints one line per k8s auth config with the contexts it matches, or the "did you mean"
+// suggestions of the orphaned configs
+func printInventory(entries []validator.InventoryEntry) {
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "K8S Auth Config Inventory:", aurora.BrightCyan(len(entries)))
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "GATEWAY\tK8S AUTH CONFIG\tK8S HOST\tSTATUS\tCONTEXTS")
+
+	for _, entry := range entries {
+		contexts := strings.Join(entry.Contexts, ",")
+		if entry.Status == validator.INVENTORY_ORPHANED {
+			suggestions := make([]string, 0, len(entry.Suggestions))
+			for _, suggestion := range entry.Suggestions {
+				suggestions = append(suggestions, fmt.Sprintf("%s (%s, %s)", suggestion.Context, suggestion.Server, suggestion.Reason))
+			}
+			contexts = "none"
+			if len(suggestions) > 0 {
+				contexts = "did you mean " + strings.Join(suggestions, " or ") + "?"
+			}
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", entry.GatewayName, entry.Name, entry.K8sHost, inventoryStatus(entry), contexts)
+	}
+
+	writer.Flush()
+}
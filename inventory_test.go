@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/akeyless-community/k8s-auth-validator/pkg/validator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintInventory(t *testing.T) {
+	previousOut := out
+	defer func() { out = previousOut }()
+	buffer := &bytes.Buffer{}
+	out = buffer
+
+	printInventory([]validator.InventoryEntry{
+		{GatewayName: "gw-prod", Name: "prod", K8sHost: "https://prod.example.com", Status: validator.INVENTORY_MATCHES_CLUSTER, Contexts: []string{"prod", "prod-admin"}},
+		{GatewayName: "gw-prod", Name: "gone", K8sHost: "https://10.20.30.40", Status: validator.INVENTORY_ORPHANED},
+		{GatewayName: "gw-prod", Name: "prod-old", K8sHost: "https://prod-old.example.com", Status: validator.INVENTORY_ORPHANED, Suggestions: []validator.InventorySuggestion{
+			{Context: "prod", Server: "https://prod.example.com", Reason: "similar K8S host"},
+		}},
+	})
+
+	assert.Contains(t, buffer.String(), "prod,prod-admin")
+	assert.Contains(t, buffer.String(), "none")
+	assert.Contains(t, buffer.String(), "did you mean prod (https://prod.example.com, similar K8S host)?")
+}
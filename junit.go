@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/akeyless-community/k8s-auth-validator/pkg/validator"
+)
+
+const OUTPUT_JUNIT = "junit"
+
+// CHECK_HOST_MATCH is only reported in the JUnit report, configs are matched to clusters by their K8S host
+const CHECK_HOST_MATCH = "host-match"
+
+type JUnitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	TestSuites []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite holds the checks of a gateway k8s auth config validated against a cluster
+type JUnitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []JUnitProperty `xml:"properties>property,omitempty"`
+	TestCases  []JUnitTestCase `xml:"testcase"`
+}
+
+type JUnitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// describeEvidence returns the evidence as sorted key=value lines
+func describeEvidence(evidence map[string]string) string {
+	keys := make([]string, 0, len(evidence))
+	for key := range evidence {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, key+"="+evidence[key])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// newJUnitTestCase converts the check into a test case, warnings pass and keep their message in the output.
+// The remediation of a check that does not pass follows its evidence or message.
+func newJUnitTestCase(className string, check validator.CheckResult) JUnitTestCase {
+	testCase := JUnitTestCase{
+		Name:      check.Name,
+		ClassName: className,
+	}
+
+	switch check.Status {
+	case validator.CHECK_STATUS_FAIL:
+		testCase.Failure = &JUnitFailure{
+			Message: check.Message,
+			Type:    string(check.Status),
+			Text:    describeEvidence(check.Evidence),
+		}
+		if len(check.Remediation) > 0 {
+			testCase.Failure.Text = strings.TrimPrefix(testCase.Failure.Text+"\n\nRemediation:\n"+check.Remediation, "\n\n")
+		}
+	case validator.CHECK_STATUS_WARN:
+		testCase.SystemOut = string(check.Status) + ": " + check.Message
+		if len(check.Remediation) > 0 {
+			testCase.SystemOut += "\n\nRemediation:\n" + check.Remediation
+		}
+	default:
+		testCase.SystemOut = check.Message
+	}
+
+	return testCase
+}
+
+// buildJUnitReport converts the report into JUnit test suites, one per gateway k8s auth config and cluster
+// pair, and one failing suite per cluster without any matching k8s auth config
+func buildJUnitReport(report Report) JUnitTestSuites {
+	testSuites := JUnitTestSuites{Name: "k8s-auth-validator"}
+	timestamp := report.GeneratedAt.Format("2006-01-02T15:04:05")
+
+	for _, cluster := range report.Clusters {
+		properties := []JUnitProperty{
+			{Name: "context", Value: cluster.Context},
+			{Name: "cluster", Value: cluster.Cluster},
+			{Name: "server", Value: cluster.Server},
+		}
+
+		if len(cluster.MatchedConfigs) == 0 {
+			testSuites.TestSuites = append(testSuites.TestSuites, JUnitTestSuite{
+				Name:       cluster.Context,
+				Timestamp:  timestamp,
+				Properties: properties,
+				TestCases: []JUnitTestCase{
+					newJUnitTestCase(cluster.Context, validator.CheckResult{
+						Name:    CHECK_HOST_MATCH,
+						Status:  validator.CHECK_STATUS_FAIL,
+						Message: "Unable to find any existing gateway k8s auth config with this kubernetes host endpoint: " + cluster.Server,
+					}),
+				},
+			})
+			continue
+		}
+
+		for _, matchedConfig := range cluster.MatchedConfigs {
+			suiteName := matchedConfig.Gateway + "/" + matchedConfig.Name
+			className := cluster.Context + "." + suiteName
+
+			testSuite := JUnitTestSuite{
+				Name:      suiteName,
+				Timestamp: timestamp,
+				Properties: append(properties,
+					JUnitProperty{Name: "gateway_url", Value: matchedConfig.GatewayUrl},
+					JUnitProperty{Name: "access_id", Value: matchedConfig.AccessId},
+				),
+			}
+			testSuite.TestCases = append(testSuite.TestCases, newJUnitTestCase(className, validator.CheckResult{
+				Name:    CHECK_HOST_MATCH,
+				Status:  validator.CHECK_STATUS_PASS,
+				Message: "K8S host matches " + matchedConfig.K8sHost,
+			}))
+			for _, check := range matchedConfig.Checks {
+				testSuite.TestCases = append(testSuite.TestCases, newJUnitTestCase(className, check))
+			}
+
+			testSuites.TestSuites = append(testSuites.TestSuites, testSuite)
+		}
+	}
+
+	for i := range testSuites.TestSuites {
+		testSuite := &testSuites.TestSuites[i]
+		testSuite.Tests = len(testSuite.TestCases)
+		for _, testCase := range testSuite.TestCases {
+			if testCase.Failure != nil {
+				testSuite.Failures++
+			}
+		}
+		testSuites.Tests += testSuite.Tests
+		testSuites.Failures += testSuite.Failures
+	}
+
+	return testSuites
+}
+
+// writeJUnitReport writes the report as a JUnit XML document
+func writeJUnitReport(writer io.Writer, report Report) error {
+	if _, err := io.WriteString(writer, xml.Header); err != nil {
+		return fmt.Errorf("unable to write the JUnit report: %w", err)
+	}
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(buildJUnitReport(report)); err != nil {
+		return fmt.Errorf("unable to write the JUnit report: %w", err)
+	}
+	_, err := io.WriteString(writer, "\n")
+	return err
+}
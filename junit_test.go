@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/akeyless-community/k8s-auth-validator/pkg/validator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildJUnitReport(t *testing.T) {
+	report := Report{
+		GeneratedAt: time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC),
+		Clusters: []ClusterReport{
+			{
+				Context: "ctx-a",
+				Server:  "https://cluster.example.com",
+				MatchedConfigs: []ConfigReport{
+					{
+						Gateway: "gw-prod",
+						Name:    "k8s-conf",
+						K8sHost: "https://cluster.example.com",
+						Checks: []validator.CheckResult{
+							{Name: validator.CHECK_CA_CERT, Status: validator.CHECK_STATUS_FAIL, Message: "CA Cert does not match", Evidence: map[string]string{"missing_fingerprints": "ab:cd"}, Remediation: "akeyless gateway-update-k8s-auth-config"},
+							{Name: validator.CHECK_REVIEWER_JWT, Status: validator.CHECK_STATUS_WARN, Message: "expires soon", Remediation: "kubectl create serviceaccount"},
+							{Name: validator.CHECK_ISSUER, Status: validator.CHECK_STATUS_FAIL, Message: "issuer does not match", Remediation: "akeyless gateway-update-k8s-auth-config"},
+							{Name: validator.CHECK_TOKEN_REVIEWER, Status: validator.CHECK_STATUS_PASS, Message: "Token Reviewer JWT Access is valid"},
+						},
+					},
+				},
+			},
+			{
+				Context: "ctx-b",
+				Server:  "https://other.example.com",
+			},
+		},
+	}
+
+	testSuites := buildJUnitReport(report)
+	assert.Equal(t, 6, testSuites.Tests)
+	assert.Equal(t, 3, testSuites.Failures)
+	assert.Len(t, testSuites.TestSuites, 2)
+
+	matchedSuite := testSuites.TestSuites[0]
+	assert.Equal(t, "gw-prod/k8s-conf", matchedSuite.Name)
+	assert.Equal(t, "2023-06-01T12:00:00", matchedSuite.Timestamp)
+	assert.Equal(t, 5, matchedSuite.Tests)
+	assert.Equal(t, 2, matchedSuite.Failures)
+	assert.Equal(t, CHECK_HOST_MATCH, matchedSuite.TestCases[0].Name)
+	assert.Nil(t, matchedSuite.TestCases[0].Failure)
+	assert.Equal(t, "ctx-a.gw-prod/k8s-conf", matchedSuite.TestCases[1].ClassName)
+	assert.Equal(t, &JUnitFailure{Message: "CA Cert does not match", Type: "FAIL", Text: "missing_fingerprints=ab:cd\n\nRemediation:\nakeyless gateway-update-k8s-auth-config"}, matchedSuite.TestCases[1].Failure)
+	assert.Nil(t, matchedSuite.TestCases[2].Failure)
+	assert.Equal(t, "WARN: expires soon\n\nRemediation:\nkubectl create serviceaccount", matchedSuite.TestCases[2].SystemOut)
+	assert.Equal(t, "Remediation:\nakeyless gateway-update-k8s-auth-config", matchedSuite.TestCases[3].Failure.Text)
+
+	unmatchedSuite := testSuites.TestSuites[1]
+	assert.Equal(t, "ctx-b", unmatchedSuite.Name)
+	assert.Equal(t, 1, unmatchedSuite.Failures)
+	assert.Equal(t, CHECK_HOST_MATCH, unmatchedSuite.TestCases[0].Name)
+	assert.Contains(t, unmatchedSuite.TestCases[0].Failure.Message, "https://other.example.com")
+
+	var buffer bytes.Buffer
+	assert.NoError(t, writeJUnitReport(&buffer, report))
+	assert.Contains(t, buffer.String(), `<testsuites name="k8s-auth-validator" tests="6" failures="3">`)
+
+	var decoded JUnitTestSuites
+	assert.NoError(t, xml.Unmarshal(buffer.Bytes(), &decoded))
+	assert.Equal(t, testSuites.TestSuites[0].TestCases, decoded.TestSuites[0].TestCases)
+}
@@ -1,118 +1,74 @@
 package main
 
 import (
-	"bytes"
 	"context"
-	"crypto/tls"
-	"encoding/base64"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
+	"io"
 	"os"
-	"path/filepath"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/akeyless-community/k8s-auth-validator/pkg/validator"
 	akeyless "github.com/akeylesslabs/akeyless-go/v2"
-	"github.com/gojek/heimdall/httpclient"
 	flags "github.com/jessevdk/go-flags"
 	"github.com/logrusorgru/aurora/v4"
 	"github.com/vito/twentythousandtonnesofcrudeoil"
-	// "k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
+// out receives all the human readable output so that stdout only holds the report in structured output modes
+var out io.Writer = os.Stdout
+
 // Declare a variable to hold the exit function. In real code, this will call os.Exit.
 var exitFunc = os.Exit
 
 // mightExit would be part of your actual application code.
 func mightExit(shouldExit bool, errorCode int) {
 	if shouldExit {
-		fmt.Println(aurora.BrightCyan("Exiting application..."))
+		fmt.Fprintln(out, aurora.BrightCyan("Exiting application..."))
 		exitFunc(errorCode)
 	}
 
-	fmt.Println("The application continues...")
-}
-
-func getKubeconfigPath() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", err
-	}
-
-	kubeconfig := filepath.Join(home, ".kube", "config")
-	return kubeconfig, nil
+	fmt.Fprintln(out, "The application continues...")
 }
 
 type Options struct {
-	Token             string `short:"t" long:"token" description:"Akeyless token" required:"false"`
-	ApiGatewayUrl     string `short:"u" long:"api-gateway-url" description:"Akeyless API Gateway URL" required:"false" default:"https://api.akeyless.io"`
-	GatewayNameFilter string `short:"g" long:"gateway-name-filter" description:"Akeyless Gateway Name Filter" required:"false"`
-	Verbose           bool   `short:"V" long:"verbose" description:"Show verbose debug information"`
-	Version           bool   `short:"v" long:"version" description:"Print the version number and exit" required:"false"`
-}
-
-type KubeAuthConfig struct {
-	Name                 string `json:"name,omitempty"`
-	ID                   string `json:"id,omitempty"`
-	ProtectionKey        string `json:"protection_key,omitempty"`
-	AuthMethodAccessID   string `json:"auth_method_access_id,omitempty"`
-	AuthMethodPrvKeyPem  string `json:"auth_method_prv_key_pem,omitempty"`
-	AmTokenExpiration    int    `json:"am_token_expiration,omitempty"`
-	K8SHost              string `json:"k8s_host,omitempty"`
-	K8SCaCert            string `json:"k8s_ca_cert,omitempty"`
-	K8STokenReviewerJwt  string `json:"k8s_token_reviewer_jwt,omitempty"`
-	K8SIssuer            string `json:"k8s_issuer,omitempty"`
-	K8SPubKeysPem        string `json:"k8s_pub_keys_pem,omitempty"`
-	DisableIssValidation bool   `json:"disable_iss_validation,omitempty"`
-	UseLocalCaJwt        bool   `json:"use_local_ca_jwt,omitempty"`
-	ClusterAPIType       string `json:"cluster_api_type,omitempty"`
-}
-
-type KubeAuthConfigs struct {
-	K8SAuths []KubeAuthConfig `json:"k8s_auths,omitempty"`
-}
-
-type GatewayKubeAuthConfigs struct {
-	KubeAuthConfigs   KubeAuthConfigs
-	GwClusterIdentity *akeyless.GwClusterIdentity
-}
-
-type TokenReviewPayload struct {
-	Kind       string `json:"kind,omitempty"`
-	APIVersion string `json:"apiVersion,omitempty"`
-	Spec       Spec   `json:"spec,omitempty"`
-}
-
-type TokenReviewResponse struct {
-	Kind       string   `json:"kind,omitempty"`
-	APIVersion string   `json:"apiVersion,omitempty"`
-	Metadata   Metadata `json:"metadata,omitempty"`
-	Spec       Spec     `json:"spec,omitempty"`
-	Status     Status   `json:"status,omitempty"`
-}
-type Metadata struct {
-	CreationTimestamp interface{} `json:"creationTimestamp,omitempty"`
-}
-type Spec struct {
-	Token string `json:"token,omitempty"`
-}
-type Extra struct {
-	AuthenticationKubernetesIoPodName []string `json:"authentication.kubernetes.io/pod-name,omitempty"`
-	AuthenticationKubernetesIoPodUID  []string `json:"authentication.kubernetes.io/pod-uid,omitempty"`
-}
-type User struct {
-	Username string   `json:"username,omitempty"`
-	UID      string   `json:"uid,omitempty"`
-	Groups   []string `json:"groups,omitempty"`
-	Extra    Extra    `json:"extra,omitempty"`
-}
-type Status struct {
-	Authenticated bool     `json:"authenticated,omitempty"`
-	User          User     `json:"user,omitempty"`
-	Audiences     []string `json:"audiences,omitempty"`
+	Token                 string        `short:"t" long:"token" description:"Akeyless token" required:"false"`
+	AccessId              string        `long:"access-id" description:"Akeyless access ID used to authenticate when no token is set" required:"false"`
+	AccessKey             string        `long:"access-key" description:"Akeyless access key used with the access_key access type" required:"false"`
+	AccessType            string        `long:"access-type" description:"Akeyless access type used with --access-id" required:"false" choice:"access_key" choice:"jwt" choice:"universal_identity" default:"access_key"`
+	Jwt                   string        `long:"jwt" description:"JWT used with the jwt access type" required:"false"`
+	UidToken              string        `long:"uid-token" description:"Universal identity token used with the universal_identity access type" required:"false"`
+	ApiGatewayUrl         string        `short:"u" long:"api-gateway-url" description:"Akeyless API Gateway URL" required:"false" default:"https://api.akeyless.io"`
+	GatewayNameFilter     string        `short:"g" long:"gateway-name-filter" description:"Akeyless Gateway Name Filter" required:"false"`
+	Kubeconfig            string        `short:"k" long:"kubeconfig" description:"Path to the kubeconfig file (defaults to the KUBECONFIG merge chain or ~/.kube/config)" required:"false"`
+	Context               string        `short:"c" long:"context" description:"The kubeconfig context to validate (defaults to the current context)" required:"false"`
+	AllContexts           bool          `short:"A" long:"all-contexts" description:"Validate every context in the kubeconfig" required:"false"`
+	ContextRegex          string        `short:"r" long:"context-regex" description:"Validate every context in the kubeconfig whose name matches this regular expression" required:"false"`
+	InCluster             bool          `short:"i" long:"in-cluster" description:"Validate the cluster the validator is running in using the pod service account" required:"false"`
+	ResolveHosts          bool          `long:"resolve-hosts" description:"Also match the k8s auth configs whose K8S host resolves to the same addresses as the cluster server" required:"false"`
+	Inventory             bool          `long:"inventory" description:"List every k8s auth config of every gateway as matching the validated cluster, matching another kubeconfig context or orphaned" required:"false"`
+	Concurrency           int           `long:"concurrency" description:"Number of gateways whose k8s auth configs are fetched in parallel" required:"false" default:"4"`
+	GatewayTimeout        time.Duration `long:"gateway-timeout" description:"Timeout for fetching the k8s auth configs of a single gateway" required:"false" default:"30s"`
+	ReviewerExpiryWarning time.Duration `long:"reviewer-expiry-warning" description:"Warn when the token reviewer JWT expires within this duration" required:"false" default:"168h"`
+	E2E                   bool          `long:"e2e" description:"Log in through each matching k8s auth config with a freshly minted service account token" required:"false"`
+	E2ENamespace          string        `long:"e2e-namespace" description:"Namespace of the service account used by --e2e (defaults to the context namespace)" required:"false"`
+	E2EServiceAccount     string        `long:"e2e-service-account" description:"Service account used by --e2e" required:"false" default:"default"`
+	E2ETokenTTL           time.Duration `long:"e2e-token-ttl" description:"Lifetime of the service account token minted by --e2e" required:"false" default:"10m"`
+	Output                string        `short:"o" long:"output" description:"Output format, json and junit write a machine-readable report to stdout and the human output to stderr" required:"false" choice:"text" choice:"json" choice:"junit" default:"text"`
+	Checks                []string      `long:"checks" description:"Only run these checks, comma separated or repeated (see --list-checks)" required:"false" env-delim:","`
+	SkipChecks            []string      `long:"skip-checks" description:"Skip these checks, comma separated or repeated (see --list-checks)" required:"false" env-delim:","`
+	ListChecks            bool          `long:"list-checks" description:"List the available checks and exit" required:"false"`
+	Watch                 bool          `short:"w" long:"watch" description:"Validate again every --watch-interval and whenever the kubeconfig changes, printing only the checks whose status changed" required:"false"`
+	WatchInterval         time.Duration `long:"watch-interval" description:"Interval between two validations in watch mode" required:"false" default:"5m"`
+	MetricsAddress        string        `long:"metrics-address" description:"Serve Prometheus metrics on this address in watch mode, e.g. :9090" required:"false"`
+	Fix                   bool          `long:"fix" description:"Update the mismatched CA cert, K8S host or dead token reviewer JWT of the matching k8s auth configs after confirmation" required:"false"`
+	Yes                   bool          `short:"y" long:"yes" description:"Apply the fixes of --fix without asking for confirmation" required:"false"`
+	DryRun                bool          `long:"dry-run" description:"Only print the fixes of --fix, or what the bootstrap command would create, without applying them" required:"false"`
+	FailOn                string        `long:"fail-on" description:"Exit with a non-zero code when a check reports this severity or worse" required:"false" choice:"warning" choice:"error" default:"error"`
+	Verbose               bool          `short:"V" long:"verbose" description:"Show verbose debug information"`
+	Version               bool          `short:"v" long:"version" description:"Print the version number and exit" required:"false"`
 }
 
 // Declare a new variable that will be set during the build process.
@@ -120,12 +76,13 @@ var version string
 var commit string
 var date string
 var timeout = 30000 * time.Millisecond
-var listAllRunningGatewayKubeConfigs = make([]GatewayKubeAuthConfigs, 0)
-var tokenReviewResponse TokenReviewResponse
 
-const GATEWAY_RUNNING_STATUS = "Running"
 const EXIT_CODE_SUCCESS = 0
 const EXIT_CODE_ERROR = 1
+const EXIT_CODE_NO_MATCHING_CONFIG = 2
+const EXIT_CODE_CHECKS_FAILED = 3
+const EXIT_CODE_AKEYLESS_ERROR = 4
+const EXIT_CODE_KUBECONFIG_ERROR = 5
 
 var options Options
 
@@ -133,72 +90,110 @@ func main() {
 
 	parser := flags.NewParser(&options, flags.HelpFlag|flags.PassDoubleDash)
 	parser.NamespaceDelimiter = "-"
+	parser.SubcommandsOptional = true
+
+	_, err := parser.AddCommand(BOOTSTRAP_COMMAND,
+		"Create a token reviewer and a k8s auth config for a cluster that no config matches",
+		"Create a token reviewer service account, its long-lived token secret and system:auth-delegator binding in the cluster, then a k8s auth config on the gateway with the server and CA certificate of the cluster. With --dry-run the manifests and the gateway request are printed instead.",
+		&bootstrapOptions)
+	handleError(parser, err)
 
 	twentythousandtonnesofcrudeoil.TheEnvironmentIsPerfectlySafe(parser, "AKEYLESS_")
 
-	_, err := parser.Parse()
+	_, err = parser.Parse()
 	handleError(parser, err)
 
+	// keep stdout for the structured report only
+	if options.Output != OUTPUT_TEXT {
+		out = os.Stderr
+	}
+
 	if options.Version {
-		fmt.Println("Version:", version)
-		fmt.Println("Commit:", commit)
-		fmt.Println("Date:", date)
+		fmt.Fprintln(out, "Version:", version)
+		fmt.Fprintln(out, "Commit:", commit)
+		fmt.Fprintln(out, "Date:", date)
 		mightExit(true, EXIT_CODE_SUCCESS)
 	}
 
-	// error if token in not set
-	if len(options.Token) == 0 {
-		printErrorMessages("", "Akeyless token is not set. Please set the token using the -t or --token flag or set the AKEYLESS_TOKEN environment variable")
-		mightExit(true, EXIT_CODE_ERROR)
+	if options.ListChecks {
+		printCheckList(os.Stdout, validator.BuiltinChecks())
+		mightExit(true, EXIT_CODE_SUCCESS)
 	}
 
-	// Get kubeconfig path
-	kubeconfig, err := getKubeconfigPath()
+	if parser.Active != nil && parser.Active.Name == BOOTSTRAP_COMMAND {
+		runBootstrap(context.Background())
+		return
+	}
+
+	checks, err := selectChecks(options)
 	if err != nil {
-		fmt.Println("Error getting kubeconfig path:", err)
+		printErrorMessages("", err.Error())
 		mightExit(true, EXIT_CODE_ERROR)
 	}
 
-	fmt.Println("Kubeconfig path:", aurora.BrightGreen(kubeconfig))
+	if options.Watch && options.Output != OUTPUT_TEXT {
+		printErrorMessages("", "The --watch flag cannot be combined with the json or junit output")
+		mightExit(true, EXIT_CODE_ERROR)
+	}
 
-	config, err := clientcmd.LoadFromFile(kubeconfig)
-	if err != nil {
-		fmt.Println("Error loading kubeconfig:", err)
+	if options.Watch && options.WatchInterval <= 0 {
+		printErrorMessages("", "The --watch-interval flag must be a positive duration")
 		mightExit(true, EXIT_CODE_ERROR)
 	}
 
-	// use the current context in kubeconfig
-	// config2, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
-	// if err != nil {
-	// 	panic(err.Error())
-	// }
+	if (options.Yes || options.DryRun) && !options.Fix {
+		printErrorMessages("", "The --yes and --dry-run flags require the --fix flag")
+		mightExit(true, EXIT_CODE_ERROR)
+	}
+
+	if options.Fix && options.Watch {
+		printErrorMessages("", "The --fix flag cannot be combined with the --watch flag")
+		mightExit(true, EXIT_CODE_ERROR)
+	}
+
+	if options.Inventory && options.Watch {
+		printErrorMessages("", "The --inventory flag cannot be combined with the --watch flag")
+		mightExit(true, EXIT_CODE_ERROR)
+	}
+
+	if len(options.MetricsAddress) > 0 && !options.Watch {
+		printErrorMessages("", "The --metrics-address flag requires the --watch flag")
+		mightExit(true, EXIT_CODE_ERROR)
+	}
 
-	// create the clientset
-	// clientset, err := kubernetes.NewForConfig(config2)
-	// if err != nil {
-	// 	panic(err.Error())
-	// }
+	// error if neither a token nor an access ID to authenticate with is set
+	if len(options.Token) == 0 && len(options.AccessId) == 0 {
+		printErrorMessages("", "Akeyless token is not set. Please set the token using the -t or --token flag or set the AKEYLESS_TOKEN environment variable, or authenticate with the --access-id flag")
+		mightExit(true, EXIT_CODE_AKEYLESS_ERROR)
+	}
 
-	currentContext := config.CurrentContext
-	contextDetails := config.Contexts[currentContext]
-	clusterDetails := config.Clusters[contextDetails.Cluster]
+	if len(options.Token) > 0 && len(options.AccessId) > 0 {
+		printErrorMessages("", "The --token flag cannot be combined with the --access-id flag")
+		mightExit(true, EXIT_CODE_ERROR)
+	}
 
-	fmt.Println("Current context:", aurora.BrightGreen(currentContext))
-	fmt.Println("Cluster:", aurora.BrightGreen(contextDetails.Cluster))
-	fmt.Println("Namespace:", aurora.BrightGreen(contextDetails.Namespace))
-	fmt.Println("User:", aurora.BrightGreen(contextDetails.AuthInfo))
+	validateManyContexts := options.AllContexts || len(options.ContextRegex) > 0
+	clusterTargets := selectClusterTargets(validateManyContexts)
 
 	if len(options.GatewayNameFilter) > 0 {
-		fmt.Println("Gateway Name Filter Flag Set:", aurora.BrightCyan(options.GatewayNameFilter))
+		fmt.Fprintln(out, "Gateway Name Filter Flag Set:", aurora.BrightCyan(options.GatewayNameFilter))
 	}
 
 	if options.ApiGatewayUrl != "https://api.akeyless.io" && len(options.ApiGatewayUrl) > 0 {
-		fmt.Println("Akeyless API Gateway URL Flag Set:", aurora.BrightCyan(options.ApiGatewayUrl))
+		fmt.Fprintln(out, "Akeyless API Gateway URL Flag Set:", aurora.BrightCyan(options.ApiGatewayUrl))
 	}
 
 	// print verbose if flag is enabled
 	if options.Verbose {
-		fmt.Println("Verbose Flag Set:", aurora.BrightCyan(options.Verbose))
+		fmt.Fprintln(out, "Verbose Flag Set:", aurora.BrightCyan(options.Verbose))
+	}
+
+	if options.E2E {
+		fmt.Fprintln(out, "E2E Flag Set:", aurora.BrightCyan(options.E2E))
+	}
+
+	if len(options.Checks) > 0 || len(options.SkipChecks) > 0 {
+		fmt.Fprintln(out, "Checks Selected:", aurora.BrightCyan(strings.Join(validator.CheckIDs(checks), ",")))
 	}
 
 	if options.ApiGatewayUrl == "" {
@@ -206,379 +201,301 @@ func main() {
 		mightExit(true, EXIT_CODE_ERROR)
 	}
 
-	base64EncodedCertificateAuthorityData := base64.StdEncoding.EncodeToString(clusterDetails.CertificateAuthorityData)
-	if options.Verbose {
-		fmt.Println("Certificate authority data:", base64EncodedCertificateAuthorityData)
-		fmt.Println("Kubernetes Cluster Endpoint Url:", clusterDetails.Server)
-	}
-
 	// Initialize Akeyless client
-	client := akeyless.NewAPIClient(&akeyless.Configuration{
-		Servers: []akeyless.ServerConfiguration{
-			{
-				URL: options.ApiGatewayUrl,
-			},
-		},
-	}).V2Api
+	client := newAkeylessClient(options.ApiGatewayUrl)
 
-	gatewayListResponse := retrieveListOfGatewaysUsingToken(client, options.Token)
+	// the token is also used to fetch the k8s auth configs from the gateways
+	if len(options.Token) == 0 {
+		options.Token = retrieveTokenUsingAccessId(client)
+	}
 
-	for _, gateway := range *gatewayListResponse.Clusters {
+	gatewayListResponse := retrieveListOfGatewaysUsingToken(client, options.Token)
 
-		// filter gateways by status so that only the status of "Running" are processed
-		var gatewayStatus = string(*gateway.Status)
+	if options.Concurrency < 1 {
+		printErrorMessages("", "The --concurrency flag must be at least 1")
+		mightExit(true, EXIT_CODE_ERROR)
+	}
 
-		if gatewayStatus != "Running" {
-			continue
-		} else {
-			// We are in a running gateway cluster so let's gather all the k8s auth configs we can get
-			var displayName = string(*gateway.DisplayName)
-			var clusterName = string(*gateway.ClusterName)
-			var usableClusterName string
-			if len(displayName) > 0 {
-				usableClusterName = displayName
-			} else {
-				usableClusterName = clusterName
+	ctx := context.Background()
+	k8sAuthValidator := newValidator(client)
+	k8sAuthValidator.Checks = checks
+
+	// watch mode runs until interrupted and replaces the one-shot validation below
+	if options.Watch {
+		watchCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		var metrics *validationMetrics
+		if len(options.MetricsAddress) > 0 {
+			metrics = newValidationMetrics()
+			metricsServer, err := metrics.serve(options.MetricsAddress)
+			if err != nil {
+				printErrorMessages("", err.Error())
+				mightExit(true, EXIT_CODE_ERROR)
 			}
+			defer metricsServer.Close()
+			fmt.Fprintln(out, "Serving metrics on:", aurora.BrightCyan(options.MetricsAddress+METRICS_PATH))
+		}
 
-			if options.Verbose {
-				fmt.Println("GW Cluster Usable Name:", usableClusterName)
-			}
+		watch(watchCtx, client, k8sAuthValidator, validateManyContexts, metrics)
+		return
+	}
 
-			// Check if the gateway cluster Url is set because we can only search for the cluster if it is set
-			if gateway.ClusterUrl == nil {
-				if options.Verbose {
-					fmt.Println("Gateway cluster URL is not set")
-				}
-				continue
-			} else {
-				var clusterUrl = string(*gateway.ClusterUrl)
-				if options.Verbose {
-					fmt.Println("Gateway cluster URL:", clusterUrl)
-				}
-			}
+	gatewayReports := k8sAuthValidator.LoadGateways(ctx, gatewayListResponse.GetClusters())
+
+	// The gateway k8s auth configs are only fetched once and then compared against every cluster
+	clusterValidations := make([]ClusterValidation, 0, len(clusterTargets))
+	for _, clusterDetails := range clusterTargets {
+		if validateManyContexts {
+			fmt.Fprintln(out)
+			fmt.Fprintln(out, "Validating context:", aurora.BrightCyan(clusterDetails.ContextName), aurora.BrightCyan(clusterDetails.Server))
 		}
-	}
 
-	lookupAllK8sAuthConfigsFromRunningGateways(*gatewayListResponse.Clusters)
-
-	foundAnyMatch := false
-
-	// loop through all the auth configs and compare the K8SHost property with the retrieved cluster endpoint of clusterDetails.Server
-	for _, gatewayKubeAuthConfig := range listAllRunningGatewayKubeConfigs {
-		for _, kubeAuthConfig := range gatewayKubeAuthConfig.KubeAuthConfigs.K8SAuths {
-			if kubeAuthConfig.K8SHost == clusterDetails.Server {
-				foundAnyMatch = true
-				fmt.Println()
-				gatewayClusterName := string(*gatewayKubeAuthConfig.GwClusterIdentity.ClusterName)
-				gatewayClusterDisplayName := string(*gatewayKubeAuthConfig.GwClusterIdentity.DisplayName)
-				fmt.Println("Found matching K8S Auth Config for Gateway Cluster:", aurora.BrightGreen(gatewayClusterName))
-				if len(gatewayClusterDisplayName) > 0 {
-					fmt.Println("Gateway Cluster Display Name:", aurora.BrightGreen(gatewayClusterDisplayName))
-				}
-				fmt.Println("Found matching K8S Auth Config for kubernetes cluster:", aurora.BrightGreen(kubeAuthConfig.K8SHost))
-				fmt.Println("K8S Auth Config Name:", aurora.BrightGreen(kubeAuthConfig.Name))
-				fmt.Println("K8S Auth Config Access ID:", aurora.BrightGreen(kubeAuthConfig.AuthMethodAccessID))
-
-				if kubeAuthConfig.K8SCaCert != base64EncodedCertificateAuthorityData {
-					fmt.Println("K8S Auth Config CA Cert does NOT match Kubernetes Auth Config Name:", aurora.BrightRed(kubeAuthConfig.K8SCaCert))
-				} else {
-					fmt.Println("K8S Auth Config CA Cert matches the Kubernetes Auth Config Name:", aurora.BrightGreen("CA Cert matches"))
-				}
-
-				// Validate Token Reviewer JWT Access
-				tokenReviewResponse, err := lookupTokenReviewerStatus(kubeAuthConfig.K8SHost+"/apis/authentication.k8s.io/v1/tokenreviews", kubeAuthConfig)
-				if err != nil {
-					fmt.Println(err)
-				}
-				if tokenReviewResponse.Status.Authenticated {
-					fmt.Println("Token Reviewer JWT Access is valid for user:", aurora.BrightGreen(tokenReviewResponse.Status.User.Username))
-				} else {
-					fmt.Println("Token Reviewer JWT Access is NOT valid for user:", aurora.BrightRed(kubeAuthConfig.K8STokenReviewerJwt))
-				}
-			}
+		findings, err := k8sAuthValidator.Run(ctx, clusterDetails)
+		if err != nil {
+			printErrorMessages(err.Error(), "Unable to validate the cluster:")
+			mightExit(true, EXIT_CODE_AKEYLESS_ERROR)
+		}
+		if len(findings) == 0 {
+			fmt.Fprintln(out)
+			printErrorMessages(clusterDetails.Server, "Unable to find any existing gateway k8s auth config with this kubernetes host endpoint:")
+			fmt.Fprintln(out, "Create one with:", aurora.BrightCyan("k8s-auth-validator bootstrap --help"))
 		}
+
+		clusterValidations = append(clusterValidations, ClusterValidation{
+			Target:     clusterDetails,
+			Findings:   findings,
+			NearMisses: k8sAuthValidator.NearMisses(ctx, clusterDetails),
+		})
 	}
 
-	if !foundAnyMatch {
-		fmt.Println()
-		printErrorMessages(clusterDetails.Server, "Unable to find any existing gateway k8s auth config with this kubernetes host endpoint:")
+	if validateManyContexts {
+		printVerdictTable(clusterValidations)
 	}
-}
 
-func retrieveListOfGatewaysUsingToken(client *akeyless.V2ApiService, token string) akeyless.GatewaysListResponse {
+	var inventory []validator.InventoryEntry
+	if options.Inventory {
+		inventory = buildInventory(ctx, k8sAuthValidator, clusterValidations)
+		printInventory(inventory)
+	}
 
-	listGatewaysBody := akeyless.ListGateways{
-		Token: &options.Token,
+	var reportErr error
+	switch options.Output {
+	case OUTPUT_JSON:
+		report := buildReport(gatewayReports, clusterValidations)
+		report.Inventory = inventory
+		reportErr = writeJSONReport(os.Stdout, report)
+	case OUTPUT_JUNIT:
+		reportErr = writeJUnitReport(os.Stdout, buildReport(gatewayReports, clusterValidations))
 	}
-	gatewayListResponse, _, err := client.ListGateways(context.Background()).Body(listGatewaysBody).Execute()
-	if err != nil {
-		printErrorMessages(err.Error(), "Unable to to retrieve list of gateways with provided token:")
+	if reportErr != nil {
+		printErrorMessages("", reportErr.Error())
 		mightExit(true, EXIT_CODE_ERROR)
 	}
-	return gatewayListResponse
-}
 
-func printErrorMessages(context string, messages ...string) {
-	fmt.Println(aurora.BrightRed("========================================================================================================================="))
-	for _, msg := range messages {
-		errorMessage := aurora.BrightRed(msg)
-		if len(context) > 0 {
-			fmt.Println(errorMessage, context)
-		} else {
-			fmt.Println(errorMessage)
-		}
+	fixFailures := 0
+	if options.Fix {
+		fixFailures = fixClusterValidations(ctx, k8sAuthValidator, clusterValidations)
 	}
-	fmt.Println(aurora.BrightRed("========================================================================================================================="))
-}
-
-func handleError(helpParser *flags.Parser, err error) {
-	if err != nil {
-		if flagsErr, ok := err.(*flags.Error); ok && flagsErr.Type == flags.ErrHelp {
-			fmt.Println(err)
-			mightExit(true, EXIT_CODE_SUCCESS)
-		} else {
-			fmt.Fprintf(os.Stderr, "error: %s\n", err)
-		}
 
-		mightExit(true, EXIT_CODE_ERROR)
+	// the exit code still reflects the validation made before the fixes
+	if exitCode := fixExitCode(validationExitCode(gatewayReports, clusterValidations, options.FailOn), fixFailures); exitCode != EXIT_CODE_SUCCESS {
+		exitFunc(exitCode)
 	}
 }
 
-func lookupK8sAuthConfigs(cluster akeyless.GwClusterIdentity) KubeAuthConfigs {
-
-	_, isClusterUrlSet := cluster.GetClusterUrlOk()
-	var k8sAuthConfigs KubeAuthConfigs
-
-	if isClusterUrlSet {
-
-		url := cluster.GetClusterUrl() + "/config/k8s-auths"
-
-		// If verbose logging is enabled then print the url
-		if options.Verbose {
-			fmt.Println("Cluster URL with k8s auth path:", url)
+// selectClusterTargets returns the clusters to validate, either the cluster the validator is running in
+// or the selected kubeconfig context(s)
+func selectClusterTargets(validateManyContexts bool) []validator.ClusterTarget {
+	if options.InCluster {
+		if len(options.Kubeconfig) > 0 || len(options.Context) > 0 || validateManyContexts {
+			printErrorMessages("", "The --in-cluster flag cannot be combined with the --kubeconfig, --context, --all-contexts or --context-regex flags")
+			mightExit(true, EXIT_CODE_ERROR)
 		}
 
-		httpRequestClient := httpclient.NewClient(httpclient.WithHTTPTimeout(timeout))
-
-		// Create an http.Request instance
-		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		fmt.Fprintln(out, "In Cluster Flag Set:", aurora.BrightCyan(options.InCluster))
 
-		bearerToken := "Bearer " + options.Token
-		req.Header.Add("Authorization", bearerToken)
-		// Call the `Do` method, which has a similar interface to the `http.Do` method
-		res, err := httpRequestClient.Do(req)
+		clusterDetails, err := validator.ResolveInClusterTarget()
 		if err != nil {
-			fmt.Println("Unable to get k8s auth configs:", cluster.GetClusterUrl(), err)
-			return generateEmptyK8sAuthConfigs()
+			fmt.Fprintln(out, "Error resolving in-cluster configuration:", err)
+			mightExit(true, EXIT_CODE_KUBECONFIG_ERROR)
 		}
 
-		body, err := ioutil.ReadAll(res.Body)
+		printClusterTargetDetails(clusterDetails)
+		return []validator.ClusterTarget{clusterDetails}
+	}
 
-		err2 := json.Unmarshal(body, &k8sAuthConfigs)
-		if err2 != nil {
-			fmt.Println(err)
-		}
+	// Load the kubeconfig honoring the --kubeconfig flag and the KUBECONFIG merge chain
+	loadingRules := validator.NewKubeconfigLoadingRules(options.Kubeconfig)
 
-		// If verbose logging is enabled then print the k8s auth configs as json
-		if options.Verbose {
-			k8sAuthConfigsJson, _ := json.Marshal(k8sAuthConfigs)
-			fmt.Println("K8s auth configs:", string(k8sAuthConfigsJson))
-		}
+	fmt.Fprintln(out, "Kubeconfig path:", aurora.BrightGreen(validator.DescribeKubeconfigSource(loadingRules)))
 
-		return k8sAuthConfigs
-	} else {
-		if options.Verbose {
-			fmt.Println("Cluster URL is not set for ", cluster.GetClusterName())
-		}
-
-		return generateEmptyK8sAuthConfigs()
+	config, err := loadingRules.Load()
+	if err != nil {
+		fmt.Fprintln(out, "Error loading kubeconfig:", err)
+		mightExit(true, EXIT_CODE_KUBECONFIG_ERROR)
 	}
-}
 
-func generateEmptyK8sAuthConfigs() KubeAuthConfigs {
-	k8sAuthConfigs := KubeAuthConfigs{
-		K8SAuths: []KubeAuthConfig{},
+	if len(options.Context) > 0 && (options.AllContexts || len(options.ContextRegex) > 0) {
+		printErrorMessages("", "The --context flag cannot be combined with the --all-contexts or --context-regex flags")
+		mightExit(true, EXIT_CODE_ERROR)
 	}
-	return k8sAuthConfigs
-}
 
-func afterLastSlash(s string) string {
-	i := strings.LastIndex(s, "/")
-	if i == -1 {
-		// No slash found, return the entire string
-		return s
-	}
-	// Return everything after the last slash
-	return s[i+1:]
-}
+	var clusterTargets []validator.ClusterTarget
 
-func lookupAllK8sAuthConfigsFromRunningGateways(listRunningGateways []akeyless.GwClusterIdentity) {
-	var lookupThisGateway bool = true
-	var clusterNameMatches bool = false
-	var clusterUrlIsConfigured bool = false
-	var clusterIsRunning bool = false
-
-	for _, g := range listRunningGateways {
-
-		if options.GatewayNameFilter != "" {
-			lookupThisGateway = false
-
-			var displayName = string(*g.DisplayName)
-			var clusterName = string(*g.ClusterName)
-			var shortClusterName = afterLastSlash(clusterName)
-			var usableClusterName string
-			DEFAULT_CLUSTER_NAME := "defaultCluster"
-			if len(displayName) > 0 {
-				usableClusterName = displayName
-			} else if len(shortClusterName) > 0 && shortClusterName != DEFAULT_CLUSTER_NAME {
-				usableClusterName = shortClusterName
-			} else {
-				usableClusterName = clusterName
-			}
+	if validateManyContexts {
+		if len(options.ContextRegex) > 0 {
+			fmt.Fprintln(out, "Context Regex Flag Set:", aurora.BrightCyan(options.ContextRegex))
+		} else {
+			fmt.Fprintln(out, "All Contexts Flag Set:", aurora.BrightCyan(options.AllContexts))
+		}
 
-			if usableClusterName != "" {
-				if options.Verbose {
-					fmt.Println("Usable Cluster Name:", aurora.BrightYellow(usableClusterName))
-				}
-			} else {
-				if options.Verbose {
-					fmt.Println("Usable Cluster Name is empty so using full cluster name")
-				}
-				usableClusterName = *g.ClusterName
-			}
+		contextNames, err := validator.ListContextNames(config, options.ContextRegex)
+		if err != nil {
+			fmt.Fprintln(out, "Error listing kubeconfig contexts:", err)
+			mightExit(true, EXIT_CODE_KUBECONFIG_ERROR)
+		}
 
-			if strings.HasPrefix(usableClusterName, options.GatewayNameFilter) {
-				if options.Verbose {
-					fmt.Println("Gateway Name Filter matches so processing gateway")
-				}
-				clusterNameMatches = true
-			} else {
-				if options.Verbose {
-					fmt.Println("Gateway Name Filter does NOT match so skipping gateway")
-				}
-				clusterNameMatches = false
+		for _, contextName := range contextNames {
+			clusterTarget, err := validator.ResolveClusterTarget(config, contextName)
+			if err != nil {
+				fmt.Fprintln(out, "Skipping context:", aurora.BrightYellow(contextName), err)
+				continue
 			}
-		} else {
-			// If no gateway name filter is set then process all gateways
-			clusterNameMatches = true
+			clusterTargets = append(clusterTargets, clusterTarget)
 		}
 
-		gClusterUrl, gClusterUrlIsSet := g.GetClusterUrlOk()
-
-		if gClusterUrlIsSet {
-			gClusterUrlString := string(*gClusterUrl)
-			if len(gClusterUrlString) > 0 {
-				if options.Verbose {
-					fmt.Println("Gateway cluster URL is set so processing gateway:", aurora.BrightYellow(gClusterUrlString))
-				}
-				clusterUrlIsConfigured = true
-			} else {
-				if options.Verbose {
-					fmt.Println("Gateway cluster URL is NOT set so skipping gateway")
-				}
-				clusterUrlIsConfigured = false
-			}
-		} else {
-			if options.Verbose {
-				fmt.Println("Gateway cluster URL is NOT set so skipping gateway")
-			}
-			clusterUrlIsConfigured = false
+		if len(clusterTargets) == 0 {
+			printErrorMessages("", "No kubeconfig contexts to validate")
+			mightExit(true, EXIT_CODE_KUBECONFIG_ERROR)
+		}
+		fmt.Fprintln(out, "Contexts to validate:", aurora.BrightGreen(len(clusterTargets)))
+	} else {
+		// use the --context flag if set, otherwise the current context in kubeconfig
+		clusterDetails, err := validator.ResolveClusterTarget(config, options.Context)
+		if err != nil {
+			fmt.Fprintln(out, "Error resolving kubeconfig context:", err)
+			mightExit(true, EXIT_CODE_KUBECONFIG_ERROR)
 		}
 
-		gStatus, gStatusIsSet := g.GetStatusOk()
-		gStatusString := string(*gStatus)
+		printClusterTargetDetails(clusterDetails)
+		clusterTargets = append(clusterTargets, clusterDetails)
+	}
 
-		if gStatusIsSet && gStatusString != GATEWAY_RUNNING_STATUS {
-			if options.Verbose {
-				fmt.Println("Gateway Status is NOT 'Running':", aurora.BrightYellow(string(*g.Status)), aurora.BrightYellow(*g.ClusterName))
-			}
+	return clusterTargets
 
-		} else {
-			if options.Verbose {
-				fmt.Println("Gateway Status is 'Running':", aurora.BrightGreen(string(*g.Status)), aurora.BrightGreen(*g.ClusterName))
-			}
-			clusterIsRunning = true
-		}
+}
 
-		// Only lookup the k8s auth configs if the cluster name matches, the cluster url is configured and the cluster is running
-		lookupThisGateway = clusterNameMatches && clusterUrlIsConfigured && clusterIsRunning
-
-		if lookupThisGateway {
-			gwKubeAuthConfigs := lookupK8sAuthConfigs(g)
-			// If there are any k8s auth configs then add them to the list
-			if len(gwKubeAuthConfigs.K8SAuths) > 0 {
-				gatewayKubeAuthConfigs := GatewayKubeAuthConfigs{
-					GwClusterIdentity: &g,
-					KubeAuthConfigs:   gwKubeAuthConfigs,
-				}
-				listAllRunningGatewayKubeConfigs = append(listAllRunningGatewayKubeConfigs, gatewayKubeAuthConfigs)
-			}
-		}
+// printClusterTargetDetails prints the kubeconfig details of the cluster being validated
+func printClusterTargetDetails(clusterDetails validator.ClusterTarget) {
+	if len(clusterDetails.ContextSource) > 0 {
+		fmt.Fprintln(out, "Context loaded from:", aurora.BrightGreen(clusterDetails.ContextSource))
+	}
+	if len(options.Context) > 0 {
+		fmt.Fprintln(out, "Context Flag Set:", aurora.BrightCyan(options.Context))
+	} else if options.InCluster {
+		fmt.Fprintln(out, "Kubernetes Service Host:", aurora.BrightGreen(os.Getenv("KUBERNETES_SERVICE_HOST")))
+	} else {
+		fmt.Fprintln(out, "Current context:", aurora.BrightGreen(clusterDetails.ContextName))
 	}
+	fmt.Fprintln(out, "Cluster:", aurora.BrightGreen(clusterDetails.ClusterName))
+	fmt.Fprintln(out, "Server:", aurora.BrightGreen(clusterDetails.Server))
+	fmt.Fprintln(out, "Namespace:", aurora.BrightGreen(clusterDetails.Namespace))
+	fmt.Fprintln(out, "User:", aurora.BrightGreen(clusterDetails.AuthInfo))
 }
 
-func lookupTokenReviewerStatus(url string, kubeAuthConfig KubeAuthConfig) (TokenReviewResponse, error) {
-	// Define a custom HTTP client with SSL check disabled.
-	customClient := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
+// newAkeylessClient returns an Akeyless V2 API client for the API Gateway URL
+func newAkeylessClient(apiGatewayUrl string) *akeyless.V2ApiService {
+	return akeyless.NewAPIClient(&akeyless.Configuration{
+		Servers: []akeyless.ServerConfiguration{
+			{
+				URL: apiGatewayUrl,
 			},
 		},
-	}
+	}).V2Api
+}
 
-	// Create a new HTTP client with a default timeout and the custom client.
-	client := httpclient.NewClient(
-		httpclient.WithHTTPTimeout(timeout),
-		httpclient.WithHTTPClient(customClient),
-	)
+// newValidator returns the validator configured from the command line options
+func newValidator(client *akeyless.V2ApiService) *validator.Validator {
+	k8sAuthValidator := validator.New(client, options.Token)
+	k8sAuthValidator.GatewayNameFilter = options.GatewayNameFilter
+	k8sAuthValidator.Concurrency = options.Concurrency
+	k8sAuthValidator.ResolveHosts = options.ResolveHosts
+	k8sAuthValidator.GatewayTimeout = options.GatewayTimeout
+	k8sAuthValidator.Timeout = timeout
+	k8sAuthValidator.ReviewerExpiryWarning = options.ReviewerExpiryWarning
+	k8sAuthValidator.E2E = validator.E2EOptions{
+		Enabled:        options.E2E,
+		Namespace:      options.E2ENamespace,
+		ServiceAccount: options.E2EServiceAccount,
+		TokenTTL:       options.E2ETokenTTL,
+	}
+	k8sAuthValidator.Out = out
+	k8sAuthValidator.Verbose = options.Verbose
+	return k8sAuthValidator
+}
 
-	var tokenReviewerJwt string = kubeAuthConfig.K8STokenReviewerJwt
+// retrieveTokenUsingAccessId authenticates to Akeyless with the access ID and the credential of the access type
+func retrieveTokenUsingAccessId(client *akeyless.V2ApiService) string {
+	fmt.Fprintln(out, "Access ID Flag Set:", aurora.BrightCyan(options.AccessId))
+	fmt.Fprintln(out, "Access Type:", aurora.BrightCyan(options.AccessType))
 
-	// Create an instance of the Spec struct.
-	tokenReviewSpec := Spec{
-		Token: tokenReviewerJwt,
-	}
-	// Create an instance of the Payload struct.
-	payload := TokenReviewPayload{
-		Kind:       "TokenReview",
-		APIVersion: "authentication.k8s.io/v1",
-		Spec:       tokenReviewSpec,
+	authBody, err := newAuthBody(options)
+	if err != nil {
+		printErrorMessages("", err.Error())
+		mightExit(true, EXIT_CODE_AKEYLESS_ERROR)
 	}
 
-	// Use the json.Marshal function to convert the Payload struct to JSON.
-	payloadJson, err := json.Marshal(payload)
+	token, err := authenticate(context.Background(), client, authBody)
 	if err != nil {
-		fmt.Println(err)
+		printErrorMessages(err.Error(), "Unable to authenticate with the provided access ID:")
+		mightExit(true, EXIT_CODE_AKEYLESS_ERROR)
 	}
 
-	// Convert payloadJson to io.Reader type
-	payloadReader := bytes.NewBuffer(payloadJson)
+	fmt.Fprintln(out, "Authenticated with Access ID:", aurora.BrightGreen(options.AccessId))
+	return token
+}
 
-	// Define the HTTP headers.
-	headers := http.Header{}
-	headers.Set("Content-Type", "application/json")
-	headers.Set("Accept", "application/json")
-	headers.Set("Authorization", "Bearer "+kubeAuthConfig.K8STokenReviewerJwt)
+func retrieveListOfGatewaysUsingToken(client *akeyless.V2ApiService, token string) akeyless.GatewaysListResponse {
 
-	// Make the POST request.
-	response, err2 := client.Post(url, payloadReader, headers)
-	if err2 != nil {
-		fmt.Println(err2)
+	if len(token) == 0 {
+		printErrorMessages("", "Akeyless token is not set. Please set the token using the -t or --token flag or set the AKEYLESS_TOKEN environment variable")
+		mightExit(true, EXIT_CODE_AKEYLESS_ERROR)
 	}
 
-	// deserialize the response body into a byte array
-	body, err3 := ioutil.ReadAll(response.Body)
-	if err3 != nil {
-		fmt.Println(err3)
+	listGatewaysBody := akeyless.ListGateways{
+		Token: &token,
 	}
+	gatewayListResponse, _, err := client.ListGateways(context.Background()).Body(listGatewaysBody).Execute()
+	if err != nil {
+		printErrorMessages(err.Error(), "Unable to to retrieve list of gateways with provided token:")
+		mightExit(true, EXIT_CODE_AKEYLESS_ERROR)
+	}
+	return gatewayListResponse
+}
 
-	// deserialize the response body into a TokenReviewResponse struct
-	err4 := json.Unmarshal(body, &tokenReviewResponse)
-	if err4 != nil {
-		fmt.Println(err4)
+func printErrorMessages(context string, messages ...string) {
+	fmt.Fprintln(out, aurora.BrightRed("========================================================================================================================="))
+	for _, msg := range messages {
+		errorMessage := aurora.BrightRed(msg)
+		if len(context) > 0 {
+			fmt.Fprintln(out, errorMessage, context)
+		} else {
+			fmt.Fprintln(out, errorMessage)
+		}
 	}
+	fmt.Fprintln(out, aurora.BrightRed("========================================================================================================================="))
+}
 
-	return tokenReviewResponse, nil
+func handleError(helpParser *flags.Parser, err error) {
+	if err != nil {
+		if flagsErr, ok := err.(*flags.Error); ok && flagsErr.Type == flags.ErrHelp {
+			fmt.Fprintln(out, err)
+			mightExit(true, EXIT_CODE_SUCCESS)
+		} else {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		}
+
+		mightExit(true, EXIT_CODE_ERROR)
+	}
 }
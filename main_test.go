@@ -1,8 +1,10 @@
 package main
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 
 	akeyless "github.com/akeylesslabs/akeyless-go/v2"
@@ -12,29 +14,41 @@ import (
 func TestRetrieveListOfGatewaysUsingToken(t *testing.T) {
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		switch r.RequestURI {
-		case "/v2/gateways":
-			// return a successful response
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte(`{"clusters": [{"name": "test-gateway"}]}`))
-		case "/v2/gateways?token=expired":
+		if r.URL.Path != "/list-gateways" {
+			http.NotFound(w, r)
+			return
+		}
+
+		var body akeyless.ListGateways
+		json.NewDecoder(r.Body).Decode(&body)
+
+		switch body.GetToken() {
+		case "expired":
 			// return a 401 error for expired tokens
 			w.WriteHeader(http.StatusUnauthorized)
 			w.Write([]byte(`{"error": "token expired"}`))
-		case "/v2/gateways?token=empty":
+		case "empty":
 			// return an empty list of gateways
 			w.WriteHeader(http.StatusOK)
 			w.Write([]byte(`{"clusters": []}`))
-		case "/v2/gateways?token=error":
+		case "error":
 			// return an error response
 			w.WriteHeader(http.StatusInternalServerError)
 			w.Write([]byte(`{"error": "internal server error"}`))
 		default:
-			http.NotFound(w, r)
+			// return a successful response
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"clusters": [{"cluster_name": "test-gateway"}]}`))
 		}
 	}))
 	defer mockServer.Close()
 
+	// panic with the exit code instead of exiting so that the exit can be asserted
+	exitFunc = func(code int) {
+		panic(code)
+	}
+	defer func() { exitFunc = os.Exit }()
+
 	// create a client with the URL of our mock server
 	client := akeyless.NewAPIClient(&akeyless.Configuration{
 		Servers: []akeyless.ServerConfiguration{
@@ -51,13 +65,13 @@ func TestRetrieveListOfGatewaysUsingToken(t *testing.T) {
 	})
 
 	t.Run("Expired token", func(t *testing.T) {
-		assert.PanicsWithValue(t, "Unable to to retrieve list of gateways with provided token:", func() {
+		assert.PanicsWithValue(t, EXIT_CODE_AKEYLESS_ERROR, func() {
 			retrieveListOfGatewaysUsingToken(client, "expired")
 		})
 	})
 
 	t.Run("Token not set", func(t *testing.T) {
-		assert.PanicsWithValue(t, "Akeyless token is not set. Please set the token using the -t or --token flag or set the AKEYLESS_TOKEN environment variable", func() {
+		assert.PanicsWithValue(t, EXIT_CODE_AKEYLESS_ERROR, func() {
 			retrieveListOfGatewaysUsingToken(client, "")
 		})
 	})
@@ -69,7 +83,7 @@ func TestRetrieveListOfGatewaysUsingToken(t *testing.T) {
 	})
 
 	t.Run("Error response from API Gateway", func(t *testing.T) {
-		assert.PanicsWithValue(t, "Unable to to retrieve list of gateways with provided token:", func() {
+		assert.PanicsWithValue(t, EXIT_CODE_AKEYLESS_ERROR, func() {
 			retrieveListOfGatewaysUsingToken(client, "error")
 		})
 	})
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/akeyless-community/k8s-auth-validator/pkg/validator"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const METRICS_PATH = "/metrics"
+
+// checkStatusValues are the values of the kav_check_status gauge, higher is worse
+var checkStatusValues = map[validator.CheckStatus]float64{
+	validator.CHECK_STATUS_PASS: 0,
+	validator.CHECK_STATUS_WARN: 1,
+	validator.CHECK_STATUS_FAIL: 2,
+}
+
+// validationMetrics exports the results of the last validation run in watch mode
+type validationMetrics struct {
+	registry             *prometheus.Registry
+	checkStatus          *prometheus.GaugeVec
+	hostMatchStatus      *prometheus.GaugeVec
+	reviewerExpiry       *prometheus.GaugeVec
+	gatewayFetchDuration *prometheus.GaugeVec
+	lastRunTimestamp     prometheus.Gauge
+}
+
+func newValidationMetrics() *validationMetrics {
+	metrics := &validationMetrics{
+		registry: prometheus.NewRegistry(),
+		checkStatus: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kav_check_status",
+			Help: "Status of the check of the gateway k8s auth config, 0 for PASS, 1 for WARN and 2 for FAIL.",
+		}, []string{"context", "gateway", "config", "check"}),
+		hostMatchStatus: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kav_host_match_status",
+			Help: "Status of the host-match check of the cluster context, 0 for PASS when a gateway k8s auth config matches its K8S host and 2 for FAIL.",
+		}, []string{"context"}),
+		reviewerExpiry: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kav_token_reviewer_expiry_seconds",
+			Help: "Seconds until the token reviewer JWT of the gateway k8s auth config expires, negative once expired.",
+		}, []string{"context", "gateway", "config"}),
+		gatewayFetchDuration: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kav_gateway_config_fetch_duration_seconds",
+			Help: "Time taken to fetch the k8s auth configs of the gateway.",
+		}, []string{"gateway"}),
+		lastRunTimestamp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "kav_last_run_timestamp",
+			Help: "Unix time of the last successful validation run.",
+		}),
+	}
+	metrics.registry.MustRegister(metrics.checkStatus, metrics.hostMatchStatus, metrics.reviewerExpiry, metrics.gatewayFetchDuration, metrics.lastRunTimestamp)
+	return metrics
+}
+
+// update replaces the exported values with the results of a validation run, so that configs and gateways
+// that are gone are no longer exported
+func (m *validationMetrics) update(gatewayReports []validator.GatewayReport, clusterValidations []ClusterValidation, now time.Time) {
+	m.checkStatus.Reset()
+	m.hostMatchStatus.Reset()
+	m.reviewerExpiry.Reset()
+	m.gatewayFetchDuration.Reset()
+
+	for _, gatewayReport := range gatewayReports {
+		if gatewayReport.FetchDuration > 0 {
+			m.gatewayFetchDuration.WithLabelValues(gatewayReport.Name).Set(gatewayReport.FetchDuration.Seconds())
+		}
+	}
+
+	// the clusters without any matching config have no check to export, only their host-match result
+	for key, check := range newWatchState(clusterValidations) {
+		if key.Check == CHECK_HOST_MATCH {
+			m.hostMatchStatus.WithLabelValues(key.Context).Set(checkStatusValues[check.Status])
+		}
+	}
+
+	// a config is checked once per context matching it, the context keeps their results apart
+	for _, clusterValidation := range clusterValidations {
+		contextName := clusterValidation.Target.ContextName
+		for _, finding := range clusterValidation.Findings {
+			for _, check := range finding.Checks {
+				m.checkStatus.WithLabelValues(contextName, finding.GatewayName, finding.KubeAuthConfig.Name, check.Name).Set(checkStatusValues[check.Status])
+
+				if check.Name != validator.CHECK_REVIEWER_JWT {
+					continue
+				}
+				if expiresAt, err := time.Parse(time.RFC3339, check.Evidence["expires_at"]); err == nil {
+					m.reviewerExpiry.WithLabelValues(contextName, finding.GatewayName, finding.KubeAuthConfig.Name).Set(expiresAt.Sub(now).Seconds())
+				}
+			}
+		}
+	}
+
+	m.lastRunTimestamp.Set(float64(now.Unix()))
+}
+
+// serve exposes the metrics on the address until the server is shut down. Listening on the address
+// fails right away so that a port already in use is reported before watching starts.
+func (m *validationMetrics) serve(address string) (*http.Server, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("unable to serve the metrics: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(METRICS_PATH, promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: timeout}
+	go server.Serve(listener)
+
+	return server, nil
+}
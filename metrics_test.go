@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akeyless-community/k8s-auth-validator/pkg/validator"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidationMetrics(t *testing.T) {
+	now := time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)
+	clusterValidation := newTestClusterValidation(validator.CHECK_STATUS_FAIL)
+	clusterValidation.Findings[0].Checks = append(clusterValidation.Findings[0].Checks, validator.CheckResult{
+		Name:     validator.CHECK_REVIEWER_JWT,
+		Status:   validator.CHECK_STATUS_WARN,
+		Evidence: map[string]string{"expires_at": now.Add(time.Hour).Format(time.RFC3339)},
+	})
+	// another context of the same server where the config passes must not hide the failure
+	otherContextValidation := newTestClusterValidation(validator.CHECK_STATUS_PASS)
+	otherContextValidation.Target.ContextName = "prod-eks-admin"
+	gatewayReports := []validator.GatewayReport{
+		{Name: "gw-prod", Considered: true, FetchDuration: 1500 * time.Millisecond},
+		{Name: "gw-stopped", SkipReason: "gateway status is not Running"},
+	}
+
+	metrics := newValidationMetrics()
+	metrics.update(gatewayReports, []ClusterValidation{clusterValidation, otherContextValidation}, now)
+
+	assert.NoError(t, testutil.GatherAndCompare(metrics.registry, strings.NewReader(`
+# HELP kav_check_status Status of the check of the gateway k8s auth config, 0 for PASS, 1 for WARN and 2 for FAIL.
+# TYPE kav_check_status gauge
+kav_check_status{check="ca-cert",config="k8s-eks",context="prod-eks",gateway="gw-prod"} 0
+kav_check_status{check="ca-cert",config="k8s-eks",context="prod-eks-admin",gateway="gw-prod"} 0
+kav_check_status{check="reviewer-jwt",config="k8s-eks",context="prod-eks",gateway="gw-prod"} 1
+kav_check_status{check="token-reviewer",config="k8s-eks",context="prod-eks",gateway="gw-prod"} 2
+kav_check_status{check="token-reviewer",config="k8s-eks",context="prod-eks-admin",gateway="gw-prod"} 0
+# HELP kav_gateway_config_fetch_duration_seconds Time taken to fetch the k8s auth configs of the gateway.
+# TYPE kav_gateway_config_fetch_duration_seconds gauge
+kav_gateway_config_fetch_duration_seconds{gateway="gw-prod"} 1.5
+# HELP kav_host_match_status Status of the host-match check of the cluster context, 0 for PASS when a gateway k8s auth config matches its K8S host and 2 for FAIL.
+# TYPE kav_host_match_status gauge
+kav_host_match_status{context="prod-eks"} 0
+kav_host_match_status{context="prod-eks-admin"} 0
+# HELP kav_last_run_timestamp Unix time of the last successful validation run.
+# TYPE kav_last_run_timestamp gauge
+kav_last_run_timestamp 1.7146440e+09
+# HELP kav_token_reviewer_expiry_seconds Seconds until the token reviewer JWT of the gateway k8s auth config expires, negative once expired.
+# TYPE kav_token_reviewer_expiry_seconds gauge
+kav_token_reviewer_expiry_seconds{config="k8s-eks",context="prod-eks",gateway="gw-prod"} 3600
+`)))
+
+	// configs that no longer match are not exported anymore, the cluster is still exported by its host-match
+	metrics.update(gatewayReports, []ClusterValidation{{Target: clusterValidation.Target}}, now)
+	assert.Equal(t, 0, testutil.CollectAndCount(metrics.checkStatus))
+	assert.Equal(t, 0, testutil.CollectAndCount(metrics.reviewerExpiry))
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.hostMatchStatus.WithLabelValues("prod-eks")))
+}
+
+func TestValidationMetricsServeError(t *testing.T) {
+	_, err := newValidationMetrics().serve("invalid-address")
+	assert.ErrorContains(t, err, "unable to serve the metrics")
+}
@@ -0,0 +1,157 @@
+package validator
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	akeyless "github.com/akeylesslabs/akeyless-go/v2"
+)
+
+const CHECK_AUTH_METHOD = "auth-method"
+
+// authMethodKeyData returns the DER, or PEM, bytes of a key that may be PEM, base64 encoded PEM or base64
+// encoded DER
+func authMethodKeyData(key string) ([]byte, error) {
+	key = strings.TrimSpace(key)
+	if strings.Contains(key, "-----BEGIN") {
+		return []byte(key), nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(key), ""))
+	if err != nil {
+		return nil, fmt.Errorf("the key is neither PEM nor base64 encoded")
+	}
+	return decoded, nil
+}
+
+// parseAuthMethodPrivateKey returns the public key of the k8s auth config private key
+func parseAuthMethodPrivateKey(authMethodPrvKeyPem string) (crypto.PublicKey, error) {
+	data, err := authMethodKeyData(authMethodPrvKeyPem)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode the auth method private key: %w", err)
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+
+	var privateKey interface{}
+	if privateKey, err = x509.ParsePKCS1PrivateKey(data); err != nil {
+		if privateKey, err = x509.ParsePKCS8PrivateKey(data); err != nil {
+			if privateKey, err = x509.ParseECPrivateKey(data); err != nil {
+				return nil, fmt.Errorf("unable to parse the auth method private key")
+			}
+		}
+	}
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported auth method private key type %T", privateKey)
+	}
+	return signer.Public(), nil
+}
+
+// parseAuthMethodPublicKey returns the public key of the auth method, the Akeyless API returns it as PEM or base64
+// encoded DER
+func parseAuthMethodPublicKey(pubKey string) (ServiceAccountSigningKey, error) {
+	if signingKeys, err := parsePublicKeysPem(pubKey); err == nil {
+		return signingKeys[0], nil
+	}
+
+	data, err := authMethodKeyData(pubKey)
+	if err != nil {
+		return ServiceAccountSigningKey{}, fmt.Errorf("unable to decode the auth method public key: %w", err)
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(data)
+	if err != nil {
+		if publicKey, err = x509.ParsePKCS1PublicKey(data); err != nil {
+			return ServiceAccountSigningKey{}, fmt.Errorf("unable to parse the auth method public key")
+		}
+	}
+	return newSigningKey("", publicKey)
+}
+
+// configPublicKeyFingerprint returns the fingerprint of the public key of the k8s auth config private key, to
+// compare with the fingerprint of the auth method public key
+func configPublicKeyFingerprint(authMethodPrvKeyPem string) (string, error) {
+	configPublicKey, err := parseAuthMethodPrivateKey(authMethodPrvKeyPem)
+	if err != nil {
+		return "", err
+	}
+	configKey, err := newSigningKey("", configPublicKey)
+	if err != nil {
+		return "", err
+	}
+	return configKey.Fingerprint, nil
+}
+
+// authMethodType returns the type of the auth method access rules
+func authMethodType(authMethod akeyless.AuthMethod) string {
+	accessInfo := authMethod.GetAccessInfo()
+	if rulesType := accessInfo.GetRulesType(); len(rulesType) > 0 {
+		return rulesType
+	}
+	if accessInfo.K8sAccessRules != nil {
+		return ACCESS_TYPE_K8S
+	}
+	return "unknown"
+}
+
+// listAuthMethods lists every auth method the token can see, page by page, by access ID
+func listAuthMethods(ctx context.Context, client *akeyless.V2ApiService, token string) (map[string]akeyless.AuthMethod, error) {
+	authMethods := map[string]akeyless.AuthMethod{}
+	body := akeyless.ListAuthMethods{Token: &token}
+	for {
+		listAuthMethodsOutput, _, err := client.ListAuthMethods(ctx).Body(body).Execute()
+		if err != nil {
+			return nil, fmt.Errorf("unable to list the auth methods: %s", DescribeAkeylessError(err))
+		}
+		for _, authMethod := range listAuthMethodsOutput.GetAuthMethods() {
+			authMethods[authMethod.GetAuthMethodAccessId()] = authMethod
+		}
+
+		nextPage := listAuthMethodsOutput.GetNextPage()
+		if len(nextPage) == 0 {
+			return authMethods, nil
+		}
+		body.PaginationToken = &nextPage
+	}
+}
+
+// getAuthMethod returns the auth method with its access rules
+func getAuthMethod(ctx context.Context, client *akeyless.V2ApiService, token string, name string) (akeyless.AuthMethod, error) {
+	authMethod, _, err := client.GetAuthMethod(ctx).Body(akeyless.GetAuthMethod{Name: name, Token: &token}).Execute()
+	if err != nil {
+		return authMethod, fmt.Errorf("unable to get the auth method %s: %s", name, DescribeAkeylessError(err))
+	}
+	return authMethod, nil
+}
+
+// configsMatchingPublicKey returns the gateway k8s auth configs, other than the target, whose private key
+// corresponds to the public key of the auth method
+func (v *Validator) configsMatchingPublicKey(target CheckTarget, publicKey ServiceAccountSigningKey) []string {
+	var configNames []string
+	for _, gatewayKubeAuthConfig := range v.gatewayKubeAuthConfigs {
+		gatewayName := GatewayDisplayName(gatewayKubeAuthConfig)
+		for _, kubeAuthConfig := range gatewayKubeAuthConfig.KubeAuthConfigs.K8SAuths {
+			if gatewayName == target.GatewayName && kubeAuthConfig.Name == target.KubeAuthConfig.Name {
+				continue
+			}
+			if fingerprint, err := configPublicKeyFingerprint(kubeAuthConfig.AuthMethodPrvKeyPem); err == nil && fingerprint == publicKey.Fingerprint {
+				configNames = append(configNames, gatewayName+"/"+kubeAuthConfig.Name)
+			}
+		}
+	}
+	return configNames
+}
+
+// remediateAuthMethod updates the k8s auth config with the private key of the auth method when they do not
+// correspond, there is nothing to run for a deleted auth method or an auth method of another type
+func remediateAuthMethod(target CheckTarget, result CheckResult) string {
+	if result.Evidence["auth_method_pub_key_fingerprint"] == "" || result.Evidence["config_pub_key_fingerprint"] == "" {
+		return ""
+	}
+	return gatewayUpdateCommand(target, gatewayUpdateFields{})
+}
@@ -0,0 +1,135 @@
+package validator
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	akeyless "github.com/akeylesslabs/akeyless-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// generateTestAuthMethodKeys returns a base64 encoded PEM private key, as stored in the k8s auth configs, and
+// the base64 encoded DER public key of an auth method
+func generateTestAuthMethodKeys(t *testing.T) (string, string) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKeyDer, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKeyPem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+	return base64.StdEncoding.EncodeToString(privateKeyPem), base64.StdEncoding.EncodeToString(publicKeyDer)
+}
+
+func TestConfigPublicKeyFingerprint(t *testing.T) {
+	privateKey, publicKey := generateTestAuthMethodKeys(t)
+	otherPrivateKey, _ := generateTestAuthMethodKeys(t)
+
+	authMethodKey, err := parseAuthMethodPublicKey(publicKey)
+	assert.NoError(t, err)
+
+	fingerprint, err := configPublicKeyFingerprint(privateKey)
+	assert.NoError(t, err)
+	assert.Equal(t, authMethodKey.Fingerprint, fingerprint)
+
+	privateKeyPem, _ := base64.StdEncoding.DecodeString(privateKey)
+	fingerprint, err = configPublicKeyFingerprint(string(privateKeyPem))
+	assert.NoError(t, err)
+	assert.Equal(t, authMethodKey.Fingerprint, fingerprint)
+
+	fingerprint, err = configPublicKeyFingerprint(otherPrivateKey)
+	assert.NoError(t, err)
+	assert.NotEqual(t, authMethodKey.Fingerprint, fingerprint)
+
+	_, err = configPublicKeyFingerprint("not a key")
+	assert.Error(t, err)
+}
+
+func TestValidateAuthMethod(t *testing.T) {
+	privateKey, publicKey := generateTestAuthMethodKeys(t)
+	otherPrivateKey, _ := generateTestAuthMethodKeys(t)
+
+	listCalls := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/list-auth-methods":
+			listCalls++
+			var body akeyless.ListAuthMethods
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.PaginationToken == nil {
+				w.Write([]byte(`{"auth_methods": [{"auth_method_access_id": "p-k8s", "auth_method_name": "/k8s/prod"}], "next_page": "2"}`))
+				return
+			}
+			w.Write([]byte(`{"auth_methods": [{"auth_method_access_id": "p-api-key", "auth_method_name": "/api-key"}]}`))
+		case "/get-auth-method":
+			var body akeyless.GetAuthMethod
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.Name == "/api-key" {
+				w.Write([]byte(`{"auth_method_name": "/api-key", "access_info": {"rules_type": "api_key", "api_key_access_rules": {}}}`))
+				return
+			}
+			fmt.Fprintf(w, `{"auth_method_name": "/k8s/prod", "access_info": {"rules_type": "k8s", "k8s_access_rules": {"pub_key": %q}}}`, publicKey)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer mockServer.Close()
+
+	v := New(newTestAkeylessClient(mockServer.URL), "t-123")
+	v.Out = io.Discard
+	v.gatewayKubeAuthConfigs = []GatewayKubeAuthConfigs{{
+		GwClusterIdentity: &akeyless.GwClusterIdentity{ClusterName: akeyless.PtrString("gw-prod")},
+		KubeAuthConfigs: KubeAuthConfigs{K8SAuths: []KubeAuthConfig{
+			{Name: "k8s-prod", AuthMethodAccessID: "p-k8s", AuthMethodPrvKeyPem: privateKey},
+		}},
+	}}
+	lookups := &clusterLookups{validator: v}
+	validate := func(kubeAuthConfig KubeAuthConfig) CheckResult {
+		return v.validateAuthMethod(context.Background(), CheckTarget{
+			Finding:   Finding{GatewayName: "gw-prod", KubeAuthConfig: kubeAuthConfig},
+			validator: v,
+			lookups:   lookups,
+		})
+	}
+
+	result := validate(KubeAuthConfig{Name: "k8s-prod", AuthMethodAccessID: "p-k8s", AuthMethodPrvKeyPem: privateKey})
+	assert.Equal(t, CHECK_STATUS_PASS, result.Status)
+	assert.Equal(t, "/k8s/prod", result.Evidence["auth_method_name"])
+
+	result = validate(KubeAuthConfig{Name: "k8s-copy", AuthMethodAccessID: "p-k8s", AuthMethodPrvKeyPem: otherPrivateKey})
+	assert.Equal(t, CHECK_STATUS_FAIL, result.Status)
+	assert.Contains(t, result.Message, "does not correspond to the K8S auth config private key, it corresponds to the private key of gw-prod/k8s-prod instead")
+	assert.Contains(t, remediateAuthMethod(CheckTarget{}, result), "gateway-update-k8s-auth-config")
+
+	result = validate(KubeAuthConfig{Name: "k8s-prod", AuthMethodAccessID: "p-api-key", AuthMethodPrvKeyPem: privateKey})
+	assert.Equal(t, CHECK_STATUS_FAIL, result.Status)
+	assert.Equal(t, "Auth method /api-key is of type api_key, not Kubernetes", result.Message)
+	assert.Empty(t, remediateAuthMethod(CheckTarget{}, result))
+
+	result = validate(KubeAuthConfig{Name: "k8s-prod", AuthMethodAccessID: "p-deleted", AuthMethodPrvKeyPem: privateKey})
+	assert.Equal(t, CHECK_STATUS_FAIL, result.Status)
+	assert.Contains(t, result.Message, "Auth method p-deleted does not exist")
+
+	result = validate(KubeAuthConfig{Name: "k8s-prod", AuthMethodAccessID: "p-k8s"})
+	assert.Equal(t, CHECK_STATUS_WARN, result.Status)
+
+	// the auth methods are only listed once per run
+	assert.Equal(t, 2, listCalls)
+
+	// without an Akeyless client the check does not apply
+	v.Akeyless = nil
+	assert.Empty(t, validate(KubeAuthConfig{Name: "k8s-prod", AuthMethodAccessID: "p-k8s"}).Status)
+}
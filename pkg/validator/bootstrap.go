@@ -0,0 +1,167 @@
+package validator
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	akeyless "github.com/akeylesslabs/akeyless-go/v2"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// Bootstrap describes a new k8s auth config for a cluster that no gateway config matches yet, along with the
+// token reviewer created in the cluster for it
+type Bootstrap struct {
+	Cluster    ClusterTarget
+	GatewayUrl string
+	// Name of the new k8s auth config
+	Name               string
+	AuthMethodAccessID string
+	// SigningKey is the private key of the auth method, either PEM or base64 encoded
+	SigningKey string
+	// TokenExpiration is the lifetime in seconds of the Akeyless tokens, 300 seconds when unset
+	TokenExpiration int64
+	// Namespace and ServiceAccount of the token reviewer
+	Namespace      string
+	ServiceAccount string
+}
+
+// reviewerTokenSecretName returns the name of the long-lived token secret of the token reviewer
+func reviewerTokenSecretName(serviceAccount string) string {
+	return serviceAccount + "-token"
+}
+
+// reviewerObjects returns the token reviewer service account, its system:auth-delegator binding and its
+// long-lived token secret
+func reviewerObjects(namespace string, serviceAccount string) (*corev1.ServiceAccount, *rbacv1.ClusterRoleBinding, *corev1.Secret) {
+	serviceAccountObject := &corev1.ServiceAccount{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+		ObjectMeta: metav1.ObjectMeta{Name: serviceAccount, Namespace: namespace},
+	}
+	clusterRoleBinding := &rbacv1.ClusterRoleBinding{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+		ObjectMeta: metav1.ObjectMeta{Name: serviceAccount + "-auth-delegator"},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: AUTH_DELEGATOR_CLUSTER_ROLE},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: serviceAccount, Namespace: namespace}},
+	}
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        reviewerTokenSecretName(serviceAccount),
+			Namespace:   namespace,
+			Annotations: map[string]string{corev1.ServiceAccountNameKey: serviceAccount},
+		},
+		Type: corev1.SecretTypeServiceAccountToken,
+	}
+	return serviceAccountObject, clusterRoleBinding, secret
+}
+
+// ReviewerManifests returns the YAML manifests of the token reviewer service account, its
+// system:auth-delegator binding and its long-lived token secret
+func ReviewerManifests(namespace string, serviceAccount string) (string, error) {
+	serviceAccountObject, clusterRoleBinding, secret := reviewerObjects(namespace, serviceAccount)
+
+	manifests := make([]string, 0, 3)
+	for _, object := range []interface{}{serviceAccountObject, clusterRoleBinding, secret} {
+		manifest, err := yaml.Marshal(object)
+		if err != nil {
+			return "", err
+		}
+		manifests = append(manifests, string(manifest))
+	}
+	return strings.Join(manifests, "---\n"), nil
+}
+
+// encodeSigningKey returns the auth method private key in the base64 encoding expected by the gateway
+func encodeSigningKey(privateKey string) string {
+	if strings.Contains(privateKey, "-----BEGIN") {
+		return base64.StdEncoding.EncodeToString([]byte(privateKey))
+	}
+	return privateKey
+}
+
+// gatewayCreateBody returns the gateway-create-k8s-auth-config request of the bootstrap
+func (v *Validator) gatewayCreateBody(bootstrap Bootstrap, k8sIssuer string, tokenReviewerJwt string) akeyless.GatewayCreateK8SAuthConfig {
+	body := akeyless.NewGatewayCreateK8SAuthConfig(bootstrap.AuthMethodAccessID, bootstrap.Cluster.Server, bootstrap.Name, encodeSigningKey(bootstrap.SigningKey))
+	body.SetToken(v.Token)
+	if len(bootstrap.Cluster.CertificateAuthorityData) > 0 {
+		body.SetK8sCaCert(base64.StdEncoding.EncodeToString(bootstrap.Cluster.CertificateAuthorityData))
+	}
+	if len(k8sIssuer) > 0 {
+		body.SetK8sIssuer(k8sIssuer)
+	}
+	if len(tokenReviewerJwt) > 0 {
+		body.SetTokenReviewerJwt(tokenReviewerJwt)
+	}
+	if bootstrap.TokenExpiration > 0 {
+		body.SetTokenExp(bootstrap.TokenExpiration)
+	}
+	return *body
+}
+
+// bootstrapIssuer returns the issuer advertised by the cluster, or an empty string to keep the default issuer
+// when the cluster does not advertise one
+func (v *Validator) bootstrapIssuer(ctx context.Context, bootstrap Bootstrap) string {
+	oidcDiscovery, err := v.lookupOIDCDiscovery(ctx, bootstrap.Cluster)
+	if err != nil {
+		return ""
+	}
+	return oidcDiscovery.Issuer
+}
+
+// BootstrapRequest returns the JSON of the gateway-create-k8s-auth-config request made by RunBootstrap, with
+// the secrets redacted. The token reviewer JWT does not exist yet so it is always redacted.
+func (v *Validator) BootstrapRequest(ctx context.Context, bootstrap Bootstrap) (string, error) {
+	v.init()
+
+	body := v.gatewayCreateBody(bootstrap, v.bootstrapIssuer(ctx, bootstrap), REDACTED_VALUE)
+	body.SetToken(REDACTED_VALUE)
+	body.SigningKey = REDACTED_VALUE
+
+	var request strings.Builder
+	encoder := json.NewEncoder(&request)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(body); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(request.String(), "\n"), nil
+}
+
+// RunBootstrap creates whatever is missing of the token reviewer in the cluster, waits for its token and
+// creates the k8s auth config on the gateway with the server, CA certificate and issuer of the cluster
+func (v *Validator) RunBootstrap(ctx context.Context, bootstrap Bootstrap) error {
+	v.init()
+
+	if len(bootstrap.SigningKey) == 0 {
+		return fmt.Errorf("the private key of the auth method %s is required to create the config", bootstrap.AuthMethodAccessID)
+	}
+	if bootstrap.Cluster.RestConfig == nil {
+		return fmt.Errorf("no kubernetes credentials available to create the token reviewer")
+	}
+	clientset, err := kubernetes.NewForConfig(bootstrap.Cluster.RestConfig)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, v.Timeout)
+	defer cancel()
+
+	tokenReviewerJwt, err := createReviewerTokenWithClientset(ctx, clientset, bootstrap.Namespace, bootstrap.ServiceAccount)
+	if err != nil {
+		return err
+	}
+
+	body := v.gatewayCreateBody(bootstrap, v.bootstrapIssuer(ctx, bootstrap), tokenReviewerJwt)
+	_, _, err = v.newGatewayApiClient(bootstrap.GatewayUrl).GatewayCreateK8SAuthConfig(ctx).Body(body).Execute()
+	if err != nil {
+		return fmt.Errorf("unable to create k8s auth config %s on gateway %s: %s", bootstrap.Name, bootstrap.GatewayUrl, DescribeAkeylessError(err))
+	}
+	return nil
+}
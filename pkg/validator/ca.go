@@ -0,0 +1,139 @@
+package validator
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+// CaComparison holds the result of comparing the cluster CA bundle against the k8s auth config CA bundle
+type CaComparison struct {
+	Matching []*x509.Certificate
+	// Missing certificates are in the cluster CA bundle but not in the k8s auth config
+	Missing []*x509.Certificate
+	// Extra certificates are in the k8s auth config but not in the cluster CA bundle
+	Extra []*x509.Certificate
+}
+
+// Matches is true when every certificate of the cluster CA bundle is trusted by the k8s auth config
+func (c CaComparison) Matches() bool {
+	return len(c.Missing) == 0 && len(c.Matching) > 0
+}
+
+// parseCertificateBundle parses every PEM encoded certificate in the bundle ignoring whitespace and
+// line ending differences, falling back to DER when the data is not PEM encoded
+func parseCertificateBundle(data []byte) ([]*x509.Certificate, error) {
+	var certificates []*x509.Certificate
+
+	rest := []byte(strings.ReplaceAll(string(data), "\r\n", "\n"))
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse certificate: %w", err)
+		}
+		certificates = append(certificates, certificate)
+	}
+
+	if len(certificates) == 0 {
+		derCertificates, err := x509.ParseCertificates(data)
+		if err != nil || len(derCertificates) == 0 {
+			return nil, fmt.Errorf("no certificates found in CA bundle")
+		}
+		certificates = derCertificates
+	}
+
+	return certificates, nil
+}
+
+// decodeK8SCaCert decodes the base64 encoded CA certificate of a k8s auth config, accepting a
+// raw PEM bundle as well since that is a common mistake when creating the config
+func decodeK8SCaCert(k8sCaCert string) ([]byte, error) {
+	trimmedCaCert := strings.TrimSpace(k8sCaCert)
+	if len(trimmedCaCert) == 0 {
+		return nil, fmt.Errorf("the k8s auth config has no CA certificate")
+	}
+	if strings.Contains(trimmedCaCert, "-----BEGIN") {
+		return []byte(trimmedCaCert), nil
+	}
+
+	// remove any whitespace or line breaks added when the value was copied
+	compactCaCert := strings.Join(strings.Fields(trimmedCaCert), "")
+	caData, err := base64.StdEncoding.DecodeString(compactCaCert)
+	if err != nil {
+		return nil, fmt.Errorf("the k8s auth config CA certificate is not valid base64: %w", err)
+	}
+	return caData, nil
+}
+
+// certificateFingerprint returns the hex encoded SHA-256 fingerprint of the certificate
+func certificateFingerprint(certificate *x509.Certificate) string {
+	fingerprint := sha256.Sum256(certificate.Raw)
+	return hex.EncodeToString(fingerprint[:])
+}
+
+// describeCertificate returns a short human readable description of the certificate
+func describeCertificate(certificate *x509.Certificate) string {
+	return fmt.Sprintf("%s (SHA-256 %s, expires %s)", certificate.Subject.String(), certificateFingerprint(certificate), certificate.NotAfter.Format("2006-01-02"))
+}
+
+// compareCaCertificates compares the cluster CA bundle against the k8s auth config CA bundle by
+// certificate fingerprint so that ordering and encoding differences are ignored
+func compareCaCertificates(clusterCaData []byte, k8sCaCert string) (CaComparison, error) {
+	var comparison CaComparison
+
+	clusterCertificates, err := parseCertificateBundle(clusterCaData)
+	if err != nil {
+		return comparison, fmt.Errorf("unable to parse the cluster CA certificate: %w", err)
+	}
+
+	configCaData, err := decodeK8SCaCert(k8sCaCert)
+	if err != nil {
+		return comparison, err
+	}
+	configCertificates, err := parseCertificateBundle(configCaData)
+	if err != nil {
+		return comparison, fmt.Errorf("unable to parse the k8s auth config CA certificate: %w", err)
+	}
+
+	configFingerprints := make(map[string]bool, len(configCertificates))
+	for _, certificate := range configCertificates {
+		configFingerprints[certificateFingerprint(certificate)] = true
+	}
+
+	clusterFingerprints := make(map[string]bool, len(clusterCertificates))
+	for _, certificate := range clusterCertificates {
+		fingerprint := certificateFingerprint(certificate)
+		if clusterFingerprints[fingerprint] {
+			continue
+		}
+		clusterFingerprints[fingerprint] = true
+
+		if configFingerprints[fingerprint] {
+			comparison.Matching = append(comparison.Matching, certificate)
+		} else {
+			comparison.Missing = append(comparison.Missing, certificate)
+		}
+	}
+
+	for _, certificate := range configCertificates {
+		fingerprint := certificateFingerprint(certificate)
+		if !clusterFingerprints[fingerprint] {
+			clusterFingerprints[fingerprint] = true
+			comparison.Extra = append(comparison.Extra, certificate)
+		}
+	}
+
+	return comparison, nil
+}
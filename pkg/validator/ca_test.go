@@ -0,0 +1,115 @@
+package validator
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// generateTestCertificatePEM returns a self signed CA certificate in PEM format
+func generateTestCertificatePEM(t *testing.T, commonName string) []byte {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestCompareCaCertificates(t *testing.T) {
+	rootCa := generateTestCertificatePEM(t, "kubernetes")
+	otherCa := generateTestCertificatePEM(t, "other")
+
+	t.Run("Identical bundles match", func(t *testing.T) {
+		comparison, err := compareCaCertificates(rootCa, base64.StdEncoding.EncodeToString(rootCa))
+		assert.NoError(t, err)
+		assert.True(t, comparison.Matches())
+		assert.Empty(t, comparison.Extra)
+	})
+
+	t.Run("Line endings, whitespace and ordering are ignored", func(t *testing.T) {
+		clusterBundle := append(append([]byte{}, rootCa...), otherCa...)
+		configBundle := strings.ReplaceAll(string(otherCa)+"\n\n"+string(rootCa), "\n", "\r\n")
+		encoded := base64.StdEncoding.EncodeToString([]byte(configBundle))
+		// wrap the base64 value the way it is often pasted
+		wrapped := encoded[:40] + "\n" + encoded[40:]
+
+		comparison, err := compareCaCertificates(clusterBundle, wrapped)
+		assert.NoError(t, err)
+		assert.True(t, comparison.Matches())
+		assert.Len(t, comparison.Matching, 2)
+	})
+
+	t.Run("Extra certificate still matches", func(t *testing.T) {
+		comparison, err := compareCaCertificates(rootCa, base64.StdEncoding.EncodeToString(append(append([]byte{}, rootCa...), otherCa...)))
+		assert.NoError(t, err)
+		assert.True(t, comparison.Matches())
+		assert.Len(t, comparison.Extra, 1)
+		assert.Equal(t, "other", comparison.Extra[0].Subject.CommonName)
+	})
+
+	t.Run("Missing certificate does not match", func(t *testing.T) {
+		comparison, err := compareCaCertificates(rootCa, base64.StdEncoding.EncodeToString(otherCa))
+		assert.NoError(t, err)
+		assert.False(t, comparison.Matches())
+		assert.Len(t, comparison.Missing, 1)
+		assert.Len(t, comparison.Extra, 1)
+	})
+
+	t.Run("Raw PEM config value is accepted", func(t *testing.T) {
+		comparison, err := compareCaCertificates(rootCa, string(rootCa))
+		assert.NoError(t, err)
+		assert.True(t, comparison.Matches())
+	})
+
+	t.Run("Invalid config value", func(t *testing.T) {
+		_, err := compareCaCertificates(rootCa, "")
+		assert.Error(t, err)
+
+		_, err = compareCaCertificates(rootCa, "not base64!")
+		assert.Error(t, err)
+	})
+}
+
+func TestResolveClusterTargetCertificateAuthorityFile(t *testing.T) {
+	rootCa := generateTestCertificatePEM(t, "kubernetes")
+	directory := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(directory, "ca.crt"), rootCa, 0600))
+
+	kubeconfigPath := writeTestKubeconfig(t, "config", strings.Replace(testKubeconfigA,
+		"server: https://a.example.com",
+		"server: https://a.example.com\n    certificate-authority: "+filepath.Join(directory, "ca.crt"), 1))
+
+	config, err := NewKubeconfigLoadingRules(kubeconfigPath).Load()
+	assert.NoError(t, err)
+
+	target, err := ResolveClusterTarget(config, "")
+	assert.NoError(t, err)
+	assert.Equal(t, rootCa, target.CertificateAuthorityData)
+}
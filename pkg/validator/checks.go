@@ -0,0 +1,208 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	akeyless "github.com/akeylesslabs/akeyless-go/v2"
+)
+
+type Severity string
+
+const SEVERITY_ERROR Severity = "error"
+const SEVERITY_WARNING Severity = "warning"
+
+// Check validates a gateway k8s auth config matching a cluster. A check that does not apply to the
+// config returns a result without a status and is left out of the finding.
+type Check interface {
+	// ID names the check in the findings and in the --checks and --skip-checks flags
+	ID() string
+	// Severity is the worst status the check reports, a warning check reports its failures as warnings
+	Severity() Severity
+	Description() string
+	Run(ctx context.Context, target CheckTarget) CheckResult
+}
+
+// CheckTarget is the gateway k8s auth config a check runs against. The embedded finding holds the
+// results of the checks that already ran against the config.
+type CheckTarget struct {
+	Finding
+	Cluster ClusterTarget
+	// Out receives the human readable output of the check
+	Out io.Writer
+
+	validator *Validator
+	lookups   *clusterLookups
+}
+
+// builtinCheck is a check implemented by a validator method
+type builtinCheck struct {
+	id          string
+	severity    Severity
+	description string
+	run         func(v *Validator, ctx context.Context, target CheckTarget) CheckResult
+	remediate   func(target CheckTarget, result CheckResult) string
+}
+
+func (c builtinCheck) ID() string {
+	return c.id
+}
+
+func (c builtinCheck) Severity() Severity {
+	return c.severity
+}
+
+func (c builtinCheck) Description() string {
+	return c.description
+}
+
+func (c builtinCheck) Run(ctx context.Context, target CheckTarget) CheckResult {
+	return c.run(target.validator, ctx, target)
+}
+
+func (c builtinCheck) Remediation(target CheckTarget, result CheckResult) string {
+	if c.remediate == nil {
+		return ""
+	}
+	return c.remediate(target, result)
+}
+
+// BuiltinChecks returns the checks run by default, in the order they run. The reviewer-rbac check only runs
+// once a TokenReview succeeded, proving the permission it looks for, so its failures are only warnings.
+func BuiltinChecks() []Check {
+	return []Check{
+		builtinCheck{CHECK_CA_CERT, SEVERITY_ERROR, "The K8S auth config CA certificate matches the cluster CA bundle", (*Validator).validateCaCertificates, remediateCaCertificate},
+		builtinCheck{CHECK_REVIEWER_JWT, SEVERITY_ERROR, "The token reviewer JWT decodes and is not expired or about to expire", (*Validator).validateReviewerJWT, remediateReviewerJWT},
+		builtinCheck{CHECK_ISSUER, SEVERITY_ERROR, "The K8S auth config issuer matches the cluster service account issuer", (*Validator).validateIssuer, remediateIssuer},
+		builtinCheck{CHECK_PUB_KEYS, SEVERITY_ERROR, "The K8S auth config public keys match the cluster signing keys, when set", (*Validator).validatePublicKeys, nil},
+		builtinCheck{CHECK_TLS, SEVERITY_ERROR, "The API server certificate is trusted by the K8S auth config CA certificate", (*Validator).validateApiServerTLS, remediateCaCertificate},
+		builtinCheck{CHECK_TOKEN_REVIEWER, SEVERITY_ERROR, "The token reviewer JWT is accepted by the TokenReview API", (*Validator).validateTokenReviewer, remediateReviewerJWT},
+		builtinCheck{CHECK_REVIEWER_RBAC, SEVERITY_WARNING, "The token reviewer may create tokenreviews, requires token-reviewer", (*Validator).validateReviewerRBAC, remediateReviewerRBAC},
+		builtinCheck{CHECK_AUTH_METHOD, SEVERITY_ERROR, "The auth method of the access ID exists, is of Kubernetes type and its public key corresponds to the K8S auth config private key", (*Validator).validateAuthMethod, remediateAuthMethod},
+		builtinCheck{CHECK_E2E_LOGIN, SEVERITY_ERROR, "A freshly minted service account token logs in to Akeyless, requires --e2e", (*Validator).validateE2ELogin, nil},
+	}
+}
+
+// CheckIDs returns the IDs of the checks
+func CheckIDs(checks []Check) []string {
+	ids := make([]string, 0, len(checks))
+	for _, check := range checks {
+		ids = append(ids, check.ID())
+	}
+	return ids
+}
+
+// SelectChecks keeps the checks named in include, or all of them when include is empty, minus the
+// checks named in exclude. Unknown check IDs are an error.
+func SelectChecks(checks []Check, include []string, exclude []string) ([]Check, error) {
+	known := make(map[string]bool, len(checks))
+	for _, check := range checks {
+		known[check.ID()] = true
+	}
+
+	var unknown []string
+	toSet := func(ids []string) map[string]bool {
+		set := make(map[string]bool, len(ids))
+		for _, id := range ids {
+			if !known[id] {
+				unknown = append(unknown, id)
+			}
+			set[id] = true
+		}
+		return set
+	}
+	included := toSet(include)
+	excluded := toSet(exclude)
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown check(s) %s, available checks are %s", strings.Join(unknown, ","), strings.Join(CheckIDs(checks), ","))
+	}
+
+	selected := []Check{}
+	for _, check := range checks {
+		if len(included) > 0 && !included[check.ID()] {
+			continue
+		}
+		if excluded[check.ID()] {
+			continue
+		}
+		selected = append(selected, check)
+	}
+	return selected, nil
+}
+
+// runCheck runs the check against the target, reporting the failures of a warning check as warnings and
+// suggesting a remediation for any result that does not pass
+func runCheck(ctx context.Context, check Check, target CheckTarget) CheckResult {
+	result := check.Run(ctx, target)
+	if len(result.Name) == 0 {
+		result.Name = check.ID()
+	}
+	if result.Status == CHECK_STATUS_FAIL && check.Severity() == SEVERITY_WARNING {
+		result.Status = CHECK_STATUS_WARN
+	}
+	if remediator, ok := check.(Remediator); ok && len(result.Remediation) == 0 && len(result.Status) > 0 && result.Status != CHECK_STATUS_PASS {
+		result.Remediation = remediator.Remediation(target, result)
+	}
+	return result
+}
+
+// clusterLookups fetches the cluster OIDC discovery document and JWKS once a check needs them, at most
+// once per cluster
+type clusterLookups struct {
+	validator *Validator
+	cluster   ClusterTarget
+
+	oidcDiscoveryFetched bool
+	oidcDiscovery        OIDCDiscovery
+	oidcDiscoveryErr     error
+
+	signingKeysFetched bool
+	signingKeys        []ServiceAccountSigningKey
+	signingKeysErr     error
+
+	authMethodsFetched bool
+	authMethods        map[string]akeyless.AuthMethod
+	authMethodsErr     error
+}
+
+func (l *clusterLookups) lookupOIDCDiscovery(ctx context.Context) (OIDCDiscovery, error) {
+	if !l.oidcDiscoveryFetched {
+		l.oidcDiscovery, l.oidcDiscoveryErr = l.validator.lookupOIDCDiscovery(ctx, l.cluster)
+		l.oidcDiscoveryFetched = true
+	}
+	return l.oidcDiscovery, l.oidcDiscoveryErr
+}
+
+func (l *clusterLookups) lookupClusterSigningKeys(ctx context.Context) ([]ServiceAccountSigningKey, error) {
+	if !l.signingKeysFetched {
+		l.signingKeys, l.signingKeysErr = l.validator.lookupClusterSigningKeys(ctx, l.cluster)
+		l.signingKeysFetched = true
+	}
+	return l.signingKeys, l.signingKeysErr
+}
+
+// lookupAuthMethods lists the auth methods of the account by access ID, the list does not depend on the cluster
+// but is fetched once per run so a watch picks up the changes
+func (l *clusterLookups) lookupAuthMethods(ctx context.Context) (map[string]akeyless.AuthMethod, error) {
+	if !l.authMethodsFetched {
+		ctx, cancel := context.WithTimeout(ctx, l.validator.Timeout)
+		defer cancel()
+		l.authMethods, l.authMethodsErr = listAuthMethods(ctx, l.validator.Akeyless, l.validator.Token)
+		l.authMethodsFetched = true
+	}
+	return l.authMethods, l.authMethodsErr
+}
+
+// reviewerUsername returns the user the token-reviewer check authenticated the token reviewer JWT as
+func (t CheckTarget) reviewerUsername() (string, bool) {
+	for _, check := range t.Checks {
+		if check.Name == CHECK_TOKEN_REVIEWER && check.Status == CHECK_STATUS_PASS {
+			return check.Evidence["username"], true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,84 @@
+package validator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCheck struct {
+	severity Severity
+	result   CheckResult
+}
+
+func (c testCheck) ID() string {
+	return "test-check"
+}
+
+func (c testCheck) Severity() Severity {
+	return c.severity
+}
+
+func (c testCheck) Description() string {
+	return "A check used by the tests"
+}
+
+func (c testCheck) Run(ctx context.Context, target CheckTarget) CheckResult {
+	return c.result
+}
+
+func TestSelectChecks(t *testing.T) {
+	checks, err := SelectChecks(BuiltinChecks(), nil, []string{CHECK_TOKEN_REVIEWER})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{CHECK_CA_CERT, CHECK_REVIEWER_JWT, CHECK_ISSUER, CHECK_PUB_KEYS, CHECK_TLS, CHECK_REVIEWER_RBAC, CHECK_AUTH_METHOD, CHECK_E2E_LOGIN}, CheckIDs(checks))
+
+	checks, err = SelectChecks(BuiltinChecks(), []string{CHECK_TLS, CHECK_CA_CERT}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{CHECK_CA_CERT, CHECK_TLS}, CheckIDs(checks))
+
+	checks, err = SelectChecks(BuiltinChecks(), []string{CHECK_TLS}, []string{CHECK_TLS})
+	assert.NoError(t, err)
+	assert.NotNil(t, checks)
+	assert.Empty(t, checks)
+
+	_, err = SelectChecks(BuiltinChecks(), []string{"tokenreview"}, []string{"ca"})
+	assert.ErrorContains(t, err, "unknown check(s) ca,tokenreview")
+}
+
+func TestRunCheck(t *testing.T) {
+	failure := CheckResult{Status: CHECK_STATUS_FAIL, Message: "broken"}
+
+	result := runCheck(context.Background(), testCheck{severity: SEVERITY_ERROR, result: failure}, CheckTarget{})
+	assert.Equal(t, "test-check", result.Name)
+	assert.Equal(t, CHECK_STATUS_FAIL, result.Status)
+
+	result = runCheck(context.Background(), testCheck{severity: SEVERITY_WARNING, result: failure}, CheckTarget{})
+	assert.Equal(t, CHECK_STATUS_WARN, result.Status)
+	assert.Equal(t, "broken", result.Message)
+
+	// the RBAC check is advisory once the TokenReview succeeded
+	for _, check := range BuiltinChecks() {
+		if check.ID() == CHECK_REVIEWER_RBAC {
+			assert.Equal(t, SEVERITY_WARNING, check.Severity())
+		} else {
+			assert.Equal(t, SEVERITY_ERROR, check.Severity(), check.ID())
+		}
+	}
+}
+
+func TestCheckDependencies(t *testing.T) {
+	v := New(nil, "")
+	v.init()
+	target := CheckTarget{validator: v}
+
+	// the RBAC check needs the username the token-reviewer check authenticated as
+	assert.Empty(t, v.validateReviewerRBAC(context.Background(), target).Status)
+	assert.Empty(t, v.validateE2ELogin(context.Background(), target).Status)
+	assert.Empty(t, v.validatePublicKeys(context.Background(), target).Status)
+
+	target.Checks = []CheckResult{{Name: CHECK_TOKEN_REVIEWER, Status: CHECK_STATUS_PASS, Evidence: map[string]string{"username": "system:serviceaccount:akeyless:reviewer"}}}
+	username, authenticated := target.reviewerUsername()
+	assert.True(t, authenticated)
+	assert.Equal(t, "system:serviceaccount:akeyless:reviewer", username)
+}
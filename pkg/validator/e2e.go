@@ -0,0 +1,67 @@
+package validator
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	akeyless "github.com/akeylesslabs/akeyless-go/v2"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const CHECK_E2E_LOGIN = "e2e-login"
+const ACCESS_TYPE_K8S = "k8s"
+
+// mintServiceAccountToken requests a short-lived token for the service account through the TokenRequest API
+func mintServiceAccountToken(ctx context.Context, clientset kubernetes.Interface, namespace string, serviceAccount string, expirationSeconds int64) (string, error) {
+	tokenRequest := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			ExpirationSeconds: &expirationSeconds,
+		},
+	}
+
+	response, err := clientset.CoreV1().ServiceAccounts(namespace).CreateToken(ctx, serviceAccount, tokenRequest, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("unable to request a token for service account %s/%s: %w", namespace, serviceAccount, err)
+	}
+
+	return response.Status.Token, nil
+}
+
+// loginWithKubernetesAuth authenticates to Akeyless with the service account token through the gateway
+// k8s auth config, exactly like a workload using the k8s auth method would
+func loginWithKubernetesAuth(ctx context.Context, client *akeyless.V2ApiService, gatewayUrl string, kubeAuthConfig KubeAuthConfig, serviceAccountToken string) (string, error) {
+	accessType := ACCESS_TYPE_K8S
+	encodedServiceAccountToken := base64.StdEncoding.EncodeToString([]byte(serviceAccountToken))
+
+	authBody := akeyless.Auth{
+		AccessId:               &kubeAuthConfig.AuthMethodAccessID,
+		AccessType:             &accessType,
+		GatewayUrl:             &gatewayUrl,
+		K8sAuthConfigName:      &kubeAuthConfig.Name,
+		K8sServiceAccountToken: &encodedServiceAccountToken,
+	}
+
+	authOutput, _, err := client.Auth(ctx).Body(authBody).Execute()
+	if err != nil {
+		return "", errors.New(DescribeAkeylessError(err))
+	}
+
+	return authOutput.GetToken(), nil
+}
+
+// DescribeAkeylessError returns the error including the response body sent back by the Akeyless API
+func DescribeAkeylessError(err error) string {
+	var openApiError akeyless.GenericOpenAPIError
+	if errors.As(err, &openApiError) {
+		body := strings.TrimSpace(string(openApiError.Body()))
+		if len(body) > 0 {
+			return fmt.Sprintf("%s: %s", openApiError.Error(), body)
+		}
+	}
+	return err.Error()
+}
@@ -0,0 +1,75 @@
+package validator
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	akeyless "github.com/akeylesslabs/akeyless-go/v2"
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newTestAkeylessClient returns an Akeyless V2 API client calling the mock server
+func newTestAkeylessClient(url string) *akeyless.V2ApiService {
+	return akeyless.NewAPIClient(&akeyless.Configuration{
+		Servers: []akeyless.ServerConfiguration{
+			{
+				URL: url,
+			},
+		},
+	}).V2Api
+}
+
+func TestMintServiceAccountToken(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clientset.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		assert.Equal(t, "token", action.GetSubresource())
+		assert.Equal(t, "akeyless", action.GetNamespace())
+		tokenRequest := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenRequest)
+		assert.Equal(t, int64(600), *tokenRequest.Spec.ExpirationSeconds)
+		tokenRequest.Status.Token = "minted-token"
+		return true, tokenRequest, nil
+	})
+
+	token, err := mintServiceAccountToken(context.Background(), clientset, "akeyless", "workload", 600)
+	assert.NoError(t, err)
+	assert.Equal(t, "minted-token", token)
+}
+
+func TestLoginWithKubernetesAuth(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		assert.Equal(t, "/auth", r.URL.Path)
+
+		var body akeyless.Auth
+		json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal(t, ACCESS_TYPE_K8S, body.GetAccessType())
+		assert.Equal(t, "https://gw.example.com:8000", body.GetGatewayUrl())
+		assert.Equal(t, "k8s-prod", body.GetK8sAuthConfigName())
+		assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("minted-token")), body.GetK8sServiceAccountToken())
+
+		if body.GetAccessId() != "p-valid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": "access denied: service account is not bound to the auth method"}`))
+			return
+		}
+		w.Write([]byte(`{"token": "t-123"}`))
+	}))
+	defer mockServer.Close()
+
+	client := newTestAkeylessClient(mockServer.URL)
+
+	token, err := loginWithKubernetesAuth(context.Background(), client, "https://gw.example.com:8000", KubeAuthConfig{Name: "k8s-prod", AuthMethodAccessID: "p-valid"}, "minted-token")
+	assert.NoError(t, err)
+	assert.Equal(t, "t-123", token)
+
+	_, err = loginWithKubernetesAuth(context.Background(), client, "https://gw.example.com:8000", KubeAuthConfig{Name: "k8s-prod", AuthMethodAccessID: "p-other"}, "minted-token")
+	assert.ErrorContains(t, err, "service account is not bound to the auth method")
+}
@@ -0,0 +1,321 @@
+package validator
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	akeyless "github.com/akeylesslabs/akeyless-go/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const FIELD_K8S_HOST = "k8s_host"
+const FIELD_K8S_CA_CERT = "k8s_ca_cert"
+const FIELD_K8S_TOKEN_REVIEWER_JWT = "k8s_token_reviewer_jwt"
+
+// GATEWAY_API_PATH is the path of the V2 API served by the gateways
+const GATEWAY_API_PATH = "/api/v2"
+
+// REVIEWER_TOKEN_POLL_INTERVAL is how often a new token secret is read until the token controller fills it
+const REVIEWER_TOKEN_POLL_INTERVAL = 500 * time.Millisecond
+
+// FieldChange describes a k8s auth config field corrected by a fix, secrets are described and never included
+type FieldChange struct {
+	Field   string
+	Current string
+	Fixed   string
+}
+
+// ConfigFix holds the corrected values of a gateway k8s auth config
+type ConfigFix struct {
+	GatewayName string
+	GatewayUrl  string
+	Cluster     ClusterTarget
+	Current     KubeAuthConfig
+	Fixed       KubeAuthConfig
+	Changes     []FieldChange
+
+	// createReviewerToken is set when a token secret of the token reviewer service account is created
+	// once the fix is applied
+	createReviewerToken    bool
+	reviewerNamespace      string
+	reviewerServiceAccount string
+}
+
+// describeCaBundle returns the fingerprints of the certificates of the k8s auth config CA certificate
+func describeCaBundle(k8sCaCert string) string {
+	if len(strings.TrimSpace(k8sCaCert)) == 0 {
+		return "<not set>"
+	}
+	caData, err := decodeK8SCaCert(k8sCaCert)
+	if err != nil {
+		return "<invalid: " + err.Error() + ">"
+	}
+	certificates, err := parseCertificateBundle(caData)
+	if err != nil {
+		return "<invalid: " + err.Error() + ">"
+	}
+	return "SHA-256 " + certificateFingerprints(certificates)
+}
+
+// describeSecret describes the secret value without revealing it
+func describeSecret(value string) string {
+	if len(value) == 0 {
+		return "<not set>"
+	}
+	return REDACTED_VALUE
+}
+
+// planCaCertFix replaces the CA certificate with the CA certificate of the kubeconfig
+func planCaCertFix(fix *ConfigFix) {
+	if len(fix.Cluster.CertificateAuthorityData) == 0 {
+		return
+	}
+	fix.Fixed.K8SCaCert = base64.StdEncoding.EncodeToString(fix.Cluster.CertificateAuthorityData)
+	fix.Changes = append(fix.Changes, FieldChange{
+		Field:   FIELD_K8S_CA_CERT,
+		Current: describeCaBundle(fix.Current.K8SCaCert),
+		Fixed:   describeCaBundle(fix.Fixed.K8SCaCert),
+	})
+}
+
+// findReviewerToken returns the name and token of a service account token secret of the service account
+func findReviewerToken(ctx context.Context, clientset kubernetes.Interface, namespace string, serviceAccount string) (string, string, error) {
+	secrets, err := clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", "", fmt.Errorf("unable to list the secrets of namespace %s: %w", namespace, err)
+	}
+	for _, secret := range secrets.Items {
+		if secret.Type != corev1.SecretTypeServiceAccountToken || secret.Annotations[corev1.ServiceAccountNameKey] != serviceAccount {
+			continue
+		}
+		if token := string(secret.Data[corev1.ServiceAccountTokenKey]); len(token) > 0 {
+			return secret.Name, token, nil
+		}
+	}
+	return "", "", nil
+}
+
+// planReviewerJWTFix replaces a dead token reviewer JWT with the token of a service account token secret of
+// the token reviewer service account, the secret is created when the fix is applied if there is none
+func (v *Validator) planReviewerJWTFix(ctx context.Context, fix *ConfigFix, finding Finding) error {
+	if fix.Cluster.RestConfig == nil {
+		return fmt.Errorf("no kubernetes credentials available to look up a token reviewer token")
+	}
+	clientset, err := kubernetes.NewForConfig(fix.Cluster.RestConfig)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, v.Timeout)
+	defer cancel()
+
+	namespace, serviceAccount := reviewerServiceAccount(CheckTarget{Finding: finding})
+	secretName, token, err := findReviewerToken(ctx, clientset, namespace, serviceAccount)
+	if err != nil {
+		return err
+	}
+	// the token of the secret is the dead token, so a new token does not fix it
+	if len(token) > 0 && token == fix.Current.K8STokenReviewerJwt {
+		return nil
+	}
+
+	change := FieldChange{Field: FIELD_K8S_TOKEN_REVIEWER_JWT, Current: describeSecret(fix.Current.K8STokenReviewerJwt)}
+	if len(token) > 0 {
+		fix.Fixed.K8STokenReviewerJwt = token
+		change.Fixed = fmt.Sprintf("token of Secret %s/%s", namespace, secretName)
+	} else {
+		fix.createReviewerToken = true
+		fix.reviewerNamespace = namespace
+		fix.reviewerServiceAccount = serviceAccount
+		change.Fixed = fmt.Sprintf("token of the new Secret %s/%s", namespace, reviewerTokenSecretName(serviceAccount))
+	}
+	fix.Changes = append(fix.Changes, change)
+	return nil
+}
+
+// PlanFixes computes the corrected values of the k8s auth configs pointing to the cluster: the CA certificate
+// of the kubeconfig when the CA certificate does not match, a new token reviewer JWT when it is dead, and the
+// server of the kubeconfig when the K8S host has the wrong scheme or port. Nothing is changed until a fix is
+// applied with ApplyFix.
+func (v *Validator) PlanFixes(ctx context.Context, clusterDetails ClusterTarget, findings []Finding) ([]ConfigFix, []error) {
+	v.init()
+
+	var fixes []ConfigFix
+	var errs []error
+	for _, finding := range findings {
+		fix := ConfigFix{
+			GatewayName: finding.GatewayName,
+			GatewayUrl:  finding.GatewayUrl,
+			Cluster:     clusterDetails,
+			Current:     finding.KubeAuthConfig,
+			Fixed:       finding.KubeAuthConfig,
+		}
+
+		if finding.StatusOf(CHECK_CA_CERT) == CHECK_STATUS_FAIL {
+			planCaCertFix(&fix)
+		}
+		// the TokenReview only trusts the config CA certificate, so a token-reviewer failure only points to a dead
+		// JWT once the API server certificate is known to be trusted
+		tokenReviewerRejected := finding.StatusOf(CHECK_TOKEN_REVIEWER) == CHECK_STATUS_FAIL && finding.StatusOf(CHECK_TLS) == CHECK_STATUS_PASS
+		if finding.StatusOf(CHECK_REVIEWER_JWT) == CHECK_STATUS_FAIL || tokenReviewerRejected {
+			if err := v.planReviewerJWTFix(ctx, &fix, finding); err != nil {
+				errs = append(errs, fmt.Errorf("unable to fix the token reviewer JWT of %s/%s: %w", fix.GatewayName, fix.Current.Name, err))
+			}
+		}
+
+		if len(fix.Changes) > 0 {
+			fixes = append(fixes, fix)
+		}
+	}
+
+	// configs pointing to the cluster with the wrong scheme or port never match it so no check ran against them
+	for _, gatewayKubeAuthConfig := range v.gatewayKubeAuthConfigs {
+		for _, kubeAuthConfig := range gatewayKubeAuthConfig.KubeAuthConfigs.K8SAuths {
+			if !differsInSchemeOrPort(kubeAuthConfig.K8SHost, clusterDetails.Server) {
+				continue
+			}
+
+			fix := ConfigFix{
+				GatewayName: GatewayDisplayName(gatewayKubeAuthConfig),
+				GatewayUrl:  gatewayKubeAuthConfig.GwClusterIdentity.GetClusterUrl(),
+				Cluster:     clusterDetails,
+				Current:     kubeAuthConfig,
+				Fixed:       kubeAuthConfig,
+			}
+			fix.Fixed.K8SHost = clusterDetails.Server
+			fix.Changes = append(fix.Changes, FieldChange{Field: FIELD_K8S_HOST, Current: kubeAuthConfig.K8SHost, Fixed: clusterDetails.Server})
+
+			if caComparison, err := compareCaCertificates(clusterDetails.CertificateAuthorityData, kubeAuthConfig.K8SCaCert); err != nil || !caComparison.Matches() {
+				planCaCertFix(&fix)
+			}
+			fixes = append(fixes, fix)
+		}
+	}
+
+	return fixes, errs
+}
+
+// createReviewerToken creates the token reviewer service account, its system:auth-delegator binding and a
+// service account token secret, and waits for the token controller to fill the token in
+func (v *Validator) createReviewerToken(ctx context.Context, fix ConfigFix) (string, error) {
+	if fix.Cluster.RestConfig == nil {
+		return "", fmt.Errorf("no kubernetes credentials available to create a token reviewer token")
+	}
+	clientset, err := kubernetes.NewForConfig(fix.Cluster.RestConfig)
+	if err != nil {
+		return "", err
+	}
+	return createReviewerTokenWithClientset(ctx, clientset, fix.reviewerNamespace, fix.reviewerServiceAccount)
+}
+
+// createReviewerTokenWithClientset creates whatever is missing of the token reviewer service account, binding
+// and token secret and returns the token
+func createReviewerTokenWithClientset(ctx context.Context, clientset kubernetes.Interface, namespace string, serviceAccount string) (string, error) {
+	serviceAccountObject, clusterRoleBinding, secret := reviewerObjects(namespace, serviceAccount)
+	secretName := secret.Name
+
+	_, err := clientset.CoreV1().ServiceAccounts(namespace).Create(ctx, serviceAccountObject, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return "", fmt.Errorf("unable to create service account %s/%s: %w", namespace, serviceAccount, err)
+	}
+
+	_, err = clientset.RbacV1().ClusterRoleBindings().Create(ctx, clusterRoleBinding, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return "", fmt.Errorf("unable to bind service account %s/%s to %s: %w", namespace, serviceAccount, AUTH_DELEGATOR_CLUSTER_ROLE, err)
+	}
+
+	_, err = clientset.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return "", fmt.Errorf("unable to create secret %s/%s: %w", namespace, secretName, err)
+	}
+
+	// the token controller fills the token in asynchronously
+	for {
+		secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("unable to read secret %s/%s: %w", namespace, secretName, err)
+		}
+		if token := string(secret.Data[corev1.ServiceAccountTokenKey]); len(token) > 0 {
+			return token, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("the token of secret %s/%s was not filled in: %w", namespace, secretName, ctx.Err())
+		case <-time.After(REVIEWER_TOKEN_POLL_INTERVAL):
+		}
+	}
+}
+
+// newGatewayApiClient returns a client of the V2 API served by the gateway
+func (v *Validator) newGatewayApiClient(gatewayUrl string) *akeyless.V2ApiService {
+	configuration := &akeyless.Configuration{
+		Servers: []akeyless.ServerConfiguration{
+			{
+				URL: strings.TrimSuffix(gatewayUrl, "/") + GATEWAY_API_PATH,
+			},
+		},
+	}
+	if httpClient, ok := v.HTTPClient.(*http.Client); ok {
+		configuration.HTTPClient = httpClient
+	}
+	return akeyless.NewAPIClient(configuration).V2Api
+}
+
+// ApplyFix updates the k8s auth config on its gateway with the corrected values, creating the token reviewer
+// token first when the fix needs one
+func (v *Validator) ApplyFix(ctx context.Context, fix ConfigFix) error {
+	v.init()
+
+	ctx, cancel := context.WithTimeout(ctx, v.Timeout)
+	defer cancel()
+
+	fixed := fix.Fixed
+	if len(fixed.AuthMethodPrvKeyPem) == 0 {
+		return fmt.Errorf("the gateway did not return the auth method private key of %s, it is required to update the config", fixed.Name)
+	}
+	if fix.createReviewerToken {
+		token, err := v.createReviewerToken(ctx, fix)
+		if err != nil {
+			return err
+		}
+		fixed.K8STokenReviewerJwt = token
+	}
+
+	body := akeyless.NewGatewayUpdateK8SAuthConfig(fixed.AuthMethodAccessID, fixed.K8SHost, fixed.Name, fixed.Name, encodeSigningKey(fixed.AuthMethodPrvKeyPem))
+	body.SetToken(v.Token)
+	body.SetDisableIssuerValidation(strconv.FormatBool(fixed.DisableIssValidation))
+	if len(fixed.K8SCaCert) > 0 {
+		body.SetK8sCaCert(fixed.K8SCaCert)
+	}
+	if len(fixed.K8STokenReviewerJwt) > 0 {
+		body.SetTokenReviewerJwt(fixed.K8STokenReviewerJwt)
+	}
+	if len(fixed.K8SIssuer) > 0 {
+		body.SetK8sIssuer(fixed.K8SIssuer)
+	}
+	if len(fixed.ClusterAPIType) > 0 {
+		body.SetClusterApiType(fixed.ClusterAPIType)
+	}
+	if fixed.AmTokenExpiration > 0 {
+		body.SetTokenExp(int64(fixed.AmTokenExpiration))
+	}
+	// the config would otherwise be encrypted with the default key again
+	if len(fixed.ProtectionKey) > 0 {
+		body.SetConfigEncryptionKeyName(fixed.ProtectionKey)
+	}
+
+	_, _, err := v.newGatewayApiClient(fix.GatewayUrl).GatewayUpdateK8SAuthConfig(ctx).Body(*body).Execute()
+	if err != nil {
+		return fmt.Errorf("unable to update k8s auth config %s on gateway %s: %s", fixed.Name, fix.GatewayUrl, DescribeAkeylessError(err))
+	}
+	return nil
+}
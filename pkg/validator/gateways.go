@@ -0,0 +1,398 @@
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	akeyless "github.com/akeylesslabs/akeyless-go/v2"
+	"github.com/logrusorgru/aurora/v4"
+)
+
+const GATEWAY_RUNNING_STATUS = "Running"
+const REDACTED_VALUE = "<redacted>"
+
+type KubeAuthConfig struct {
+	Name                 string `json:"name,omitempty"`
+	ID                   string `json:"id,omitempty"`
+	ProtectionKey        string `json:"protection_key,omitempty"`
+	AuthMethodAccessID   string `json:"auth_method_access_id,omitempty"`
+	AuthMethodPrvKeyPem  string `json:"auth_method_prv_key_pem,omitempty"`
+	AmTokenExpiration    int    `json:"am_token_expiration,omitempty"`
+	K8SHost              string `json:"k8s_host,omitempty"`
+	K8SCaCert            string `json:"k8s_ca_cert,omitempty"`
+	K8STokenReviewerJwt  string `json:"k8s_token_reviewer_jwt,omitempty"`
+	K8SIssuer            string `json:"k8s_issuer,omitempty"`
+	K8SPubKeysPem        string `json:"k8s_pub_keys_pem,omitempty"`
+	DisableIssValidation bool   `json:"disable_iss_validation,omitempty"`
+	UseLocalCaJwt        bool   `json:"use_local_ca_jwt,omitempty"`
+	ClusterAPIType       string `json:"cluster_api_type,omitempty"`
+}
+
+type KubeAuthConfigs struct {
+	K8SAuths []KubeAuthConfig `json:"k8s_auths,omitempty"`
+}
+
+type GatewayKubeAuthConfigs struct {
+	KubeAuthConfigs   KubeAuthConfigs
+	GwClusterIdentity *akeyless.GwClusterIdentity
+}
+
+// GatewayReport describes a gateway and whether its k8s auth configs were considered
+type GatewayReport struct {
+	Name           string   `json:"name"`
+	ClusterName    string   `json:"cluster_name"`
+	DisplayName    string   `json:"display_name,omitempty"`
+	ClusterUrl     string   `json:"cluster_url,omitempty"`
+	Status         string   `json:"status,omitempty"`
+	Considered     bool     `json:"considered"`
+	SkipReason     string   `json:"skip_reason,omitempty"`
+	K8sAuthConfigs []string `json:"k8s_auth_configs"`
+	// FetchDuration is how long fetching the k8s auth configs took, zero when the gateway was skipped
+	FetchDuration time.Duration `json:"-"`
+	// FetchErr is the error fetching the k8s auth configs, nil when they were fetched or the gateway was skipped
+	FetchErr error `json:"-"`
+}
+
+// GatewayStatusError is returned when a gateway answers the k8s auth configs request with an unexpected
+// HTTP status
+type GatewayStatusError struct {
+	Url        string
+	StatusCode int
+	Status     string
+}
+
+func (e *GatewayStatusError) Error() string {
+	return fmt.Sprintf("unable to get k8s auth configs from %s: %s", e.Url, e.Status)
+}
+
+// IsGatewayAuthError reports whether the gateway rejected the token used to fetch its k8s auth configs
+func IsGatewayAuthError(err error) bool {
+	var statusErr *GatewayStatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	return statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden
+}
+
+// HTTPDoer sends the HTTP requests fetching the k8s auth configs from the gateways, it is satisfied by
+// *http.Client
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// GatewayLookupResult holds the k8s auth configs fetched from a gateway, or the error fetching them
+type GatewayLookupResult struct {
+	KubeAuthConfigs KubeAuthConfigs
+	Err             error
+	Duration        time.Duration
+}
+
+// lookupK8sAuthConfigsConcurrently fetches the k8s auth configs of the gateways with at most concurrency
+// lookups in flight. Each result is stored at the index of its gateway so the order is deterministic.
+func lookupK8sAuthConfigsConcurrently(gateways []akeyless.GwClusterIdentity, concurrency int, lookup func(akeyless.GwClusterIdentity) (KubeAuthConfigs, error)) []GatewayLookupResult {
+	results := make([]GatewayLookupResult, len(gateways))
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	semaphore := make(chan struct{}, concurrency)
+	var waitGroup sync.WaitGroup
+	for i := range gateways {
+		waitGroup.Add(1)
+		semaphore <- struct{}{}
+		go func(i int) {
+			defer waitGroup.Done()
+			defer func() { <-semaphore }()
+
+			start := time.Now()
+			kubeAuthConfigs, err := lookup(gateways[i])
+			results[i] = GatewayLookupResult{
+				KubeAuthConfigs: kubeAuthConfigs,
+				Err:             err,
+				Duration:        time.Since(start),
+			}
+		}(i)
+	}
+	waitGroup.Wait()
+
+	return results
+}
+
+// newGatewayReport describes the gateway before its k8s auth configs are looked up
+func newGatewayReport(gateway akeyless.GwClusterIdentity) GatewayReport {
+	return GatewayReport{
+		Name:           GatewayDisplayName(GatewayKubeAuthConfigs{GwClusterIdentity: &gateway}),
+		ClusterName:    gateway.GetClusterName(),
+		DisplayName:    gateway.GetDisplayName(),
+		ClusterUrl:     gateway.GetClusterUrl(),
+		Status:         gateway.GetStatus(),
+		K8sAuthConfigs: []string{},
+	}
+}
+
+// lookupK8sAuthConfigs fetches the k8s auth configs of the gateway. It is called concurrently so it only
+// returns errors and leaves printing to the caller.
+func lookupK8sAuthConfigs(ctx context.Context, httpClient HTTPDoer, cluster akeyless.GwClusterIdentity, token string, gatewayTimeout time.Duration) (KubeAuthConfigs, error) {
+
+	_, isClusterUrlSet := cluster.GetClusterUrlOk()
+	var k8sAuthConfigs KubeAuthConfigs
+
+	if isClusterUrlSet {
+
+		url := k8sAuthConfigsUrl(cluster)
+
+		ctx, cancel := context.WithTimeout(ctx, gatewayTimeout)
+		defer cancel()
+
+		// Create an http.Request instance
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+
+		bearerToken := "Bearer " + token
+		req.Header.Add("Authorization", bearerToken)
+		// Call the `Do` method, which has a similar interface to the `http.Do` method
+		res, err := httpClient.Do(req)
+		if err != nil {
+			return generateEmptyK8sAuthConfigs(), fmt.Errorf("unable to get k8s auth configs from %s: %w", url, err)
+		}
+		defer res.Body.Close()
+
+		body, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			return generateEmptyK8sAuthConfigs(), fmt.Errorf("unable to read k8s auth configs from %s: %w", url, err)
+		}
+
+		if res.StatusCode != http.StatusOK {
+			return generateEmptyK8sAuthConfigs(), &GatewayStatusError{Url: url, StatusCode: res.StatusCode, Status: res.Status}
+		}
+
+		err2 := json.Unmarshal(body, &k8sAuthConfigs)
+		if err2 != nil {
+			return generateEmptyK8sAuthConfigs(), fmt.Errorf("unable to parse k8s auth configs from %s: %w", url, err2)
+		}
+
+		return k8sAuthConfigs, nil
+	} else {
+		return generateEmptyK8sAuthConfigs(), nil
+	}
+}
+
+func k8sAuthConfigsUrl(cluster akeyless.GwClusterIdentity) string {
+	return cluster.GetClusterUrl() + "/config/k8s-auths"
+}
+
+// redactKubeAuthConfigs returns a copy of the k8s auth configs with the token reviewer JWT and
+// private key masked so they are never printed
+func redactKubeAuthConfigs(k8sAuthConfigs KubeAuthConfigs) KubeAuthConfigs {
+	redactedConfigs := KubeAuthConfigs{
+		K8SAuths: make([]KubeAuthConfig, 0, len(k8sAuthConfigs.K8SAuths)),
+	}
+	for _, kubeAuthConfig := range k8sAuthConfigs.K8SAuths {
+		if len(kubeAuthConfig.K8STokenReviewerJwt) > 0 {
+			kubeAuthConfig.K8STokenReviewerJwt = REDACTED_VALUE
+		}
+		if len(kubeAuthConfig.AuthMethodPrvKeyPem) > 0 {
+			kubeAuthConfig.AuthMethodPrvKeyPem = REDACTED_VALUE
+		}
+		redactedConfigs.K8SAuths = append(redactedConfigs.K8SAuths, kubeAuthConfig)
+	}
+	return redactedConfigs
+}
+
+func generateEmptyK8sAuthConfigs() KubeAuthConfigs {
+	k8sAuthConfigs := KubeAuthConfigs{
+		K8SAuths: []KubeAuthConfig{},
+	}
+	return k8sAuthConfigs
+}
+
+func afterLastSlash(s string) string {
+	i := strings.LastIndex(s, "/")
+	if i == -1 {
+		// No slash found, return the entire string
+		return s
+	}
+	// Return everything after the last slash
+	return s[i+1:]
+}
+
+// LoadGateways gathers the k8s auth configs of every running gateway matching the gateway name filter,
+// keeps them for the following runs and returns a report of every gateway, including the reason a
+// gateway was skipped, sorted by gateway name
+func (v *Validator) LoadGateways(ctx context.Context, listRunningGateways []akeyless.GwClusterIdentity) []GatewayReport {
+	v.init()
+
+	var lookupThisGateway bool = true
+	var clusterNameMatches bool = false
+	var clusterUrlIsConfigured bool = false
+	var clusterIsRunning bool = false
+
+	gatewayReports := make([]GatewayReport, 0, len(listRunningGateways))
+	var gatewaysToLookup []akeyless.GwClusterIdentity
+	var reportIndexes []int
+
+	for _, gateway := range listRunningGateways {
+		g := gateway
+		clusterIsRunning = false
+		gatewayReport := newGatewayReport(g)
+
+		if v.GatewayNameFilter != "" {
+			lookupThisGateway = false
+
+			var displayName = g.GetDisplayName()
+			var clusterName = g.GetClusterName()
+			var shortClusterName = afterLastSlash(clusterName)
+			var usableClusterName string
+			DEFAULT_CLUSTER_NAME := "defaultCluster"
+			if len(displayName) > 0 {
+				usableClusterName = displayName
+			} else if len(shortClusterName) > 0 && shortClusterName != DEFAULT_CLUSTER_NAME {
+				usableClusterName = shortClusterName
+			} else {
+				usableClusterName = clusterName
+			}
+
+			if usableClusterName != "" {
+				if v.Verbose {
+					fmt.Fprintln(v.Out, "Usable Cluster Name:", aurora.BrightYellow(usableClusterName))
+				}
+			} else {
+				if v.Verbose {
+					fmt.Fprintln(v.Out, "Usable Cluster Name is empty so using full cluster name")
+				}
+				usableClusterName = g.GetClusterName()
+			}
+
+			if strings.HasPrefix(usableClusterName, v.GatewayNameFilter) {
+				if v.Verbose {
+					fmt.Fprintln(v.Out, "Gateway Name Filter matches so processing gateway")
+				}
+				clusterNameMatches = true
+			} else {
+				if v.Verbose {
+					fmt.Fprintln(v.Out, "Gateway Name Filter does NOT match so skipping gateway")
+				}
+				clusterNameMatches = false
+			}
+		} else {
+			// If no gateway name filter is set then process all gateways
+			clusterNameMatches = true
+		}
+
+		gClusterUrl, gClusterUrlIsSet := g.GetClusterUrlOk()
+
+		if gClusterUrlIsSet {
+			gClusterUrlString := string(*gClusterUrl)
+			if len(gClusterUrlString) > 0 {
+				if v.Verbose {
+					fmt.Fprintln(v.Out, "Gateway cluster URL is set so processing gateway:", aurora.BrightYellow(gClusterUrlString))
+				}
+				clusterUrlIsConfigured = true
+			} else {
+				if v.Verbose {
+					fmt.Fprintln(v.Out, "Gateway cluster URL is NOT set so skipping gateway")
+				}
+				clusterUrlIsConfigured = false
+			}
+		} else {
+			if v.Verbose {
+				fmt.Fprintln(v.Out, "Gateway cluster URL is NOT set so skipping gateway")
+			}
+			clusterUrlIsConfigured = false
+		}
+
+		gStatusString, gStatusIsSet := g.GetStatusOk()
+
+		if gStatusIsSet && *gStatusString != GATEWAY_RUNNING_STATUS {
+			if v.Verbose {
+				fmt.Fprintln(v.Out, "Gateway Status is NOT 'Running':", aurora.BrightYellow(g.GetStatus()), aurora.BrightYellow(g.GetClusterName()))
+			}
+
+		} else {
+			if v.Verbose {
+				fmt.Fprintln(v.Out, "Gateway Status is 'Running':", aurora.BrightGreen(g.GetStatus()), aurora.BrightGreen(g.GetClusterName()))
+			}
+			clusterIsRunning = true
+		}
+
+		// Only lookup the k8s auth configs if the cluster name matches, the cluster url is configured and the cluster is running
+		lookupThisGateway = clusterNameMatches && clusterUrlIsConfigured && clusterIsRunning
+
+		switch {
+		case !clusterNameMatches:
+			gatewayReport.SkipReason = "gateway name filter does not match"
+		case !clusterUrlIsConfigured:
+			gatewayReport.SkipReason = "gateway cluster URL is not set"
+		case !clusterIsRunning:
+			gatewayReport.SkipReason = "gateway status is not " + GATEWAY_RUNNING_STATUS
+		}
+
+		if lookupThisGateway {
+			gatewaysToLookup = append(gatewaysToLookup, g)
+			reportIndexes = append(reportIndexes, len(gatewayReports))
+		}
+
+		gatewayReports = append(gatewayReports, gatewayReport)
+	}
+
+	lookupResults := lookupK8sAuthConfigsConcurrently(gatewaysToLookup, v.Concurrency, func(gateway akeyless.GwClusterIdentity) (KubeAuthConfigs, error) {
+		return lookupK8sAuthConfigs(ctx, v.HTTPClient, gateway, v.Token, v.GatewayTimeout)
+	})
+
+	gatewayKubeAuthConfigs := make([]GatewayKubeAuthConfigs, 0, len(lookupResults))
+	for i, lookupResult := range lookupResults {
+		gatewayReport := &gatewayReports[reportIndexes[i]]
+		gatewayReport.FetchDuration = lookupResult.Duration
+		gateway := gatewaysToLookup[i]
+
+		// If verbose logging is enabled then print the url
+		if v.Verbose {
+			fmt.Fprintln(v.Out, "Cluster URL with k8s auth path:", k8sAuthConfigsUrl(gateway), aurora.BrightYellow(lookupResult.Duration.Round(time.Millisecond)))
+		}
+
+		if lookupResult.Err != nil {
+			fmt.Fprintln(v.Out, "Unable to get k8s auth configs:", gatewayReport.Name, lookupResult.Err)
+			gatewayReport.SkipReason = lookupResult.Err.Error()
+			gatewayReport.FetchErr = lookupResult.Err
+			continue
+		}
+		gatewayReport.Considered = true
+
+		// If verbose logging is enabled then print the k8s auth configs as json without their secrets
+		if v.Verbose {
+			k8sAuthConfigsJson, _ := json.Marshal(redactKubeAuthConfigs(lookupResult.KubeAuthConfigs))
+			fmt.Fprintln(v.Out, "K8s auth configs:", string(k8sAuthConfigsJson))
+		}
+
+		for _, kubeAuthConfig := range lookupResult.KubeAuthConfigs.K8SAuths {
+			gatewayReport.K8sAuthConfigs = append(gatewayReport.K8sAuthConfigs, kubeAuthConfig.Name)
+		}
+		// If there are any k8s auth configs then add them to the list
+		if len(lookupResult.KubeAuthConfigs.K8SAuths) > 0 {
+			gatewayKubeAuthConfigs = append(gatewayKubeAuthConfigs, GatewayKubeAuthConfigs{
+				GwClusterIdentity: &gatewaysToLookup[i],
+				KubeAuthConfigs:   lookupResult.KubeAuthConfigs,
+			})
+		}
+	}
+
+	// sort by gateway so the output does not depend on the order the gateways were listed in
+	sort.SliceStable(gatewayReports, func(i, j int) bool {
+		return gatewayReports[i].Name < gatewayReports[j].Name
+	})
+	sort.SliceStable(gatewayKubeAuthConfigs, func(i, j int) bool {
+		return GatewayDisplayName(gatewayKubeAuthConfigs[i]) < GatewayDisplayName(gatewayKubeAuthConfigs[j])
+	})
+
+	v.gatewayKubeAuthConfigs = gatewayKubeAuthConfigs
+	v.gatewaysLoaded = true
+
+	return gatewayReports
+}
@@ -0,0 +1,85 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	akeyless "github.com/akeylesslabs/akeyless-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupK8sAuthConfigsConcurrently(t *testing.T) {
+	clusterNames := []string{"gw-slow", "gw-down", "gw-a", "gw-b", "gw-c"}
+	gateways := make([]akeyless.GwClusterIdentity, 0, len(clusterNames))
+	for _, clusterName := range clusterNames {
+		name := clusterName
+		gateways = append(gateways, akeyless.GwClusterIdentity{ClusterName: &name})
+	}
+
+	var inFlight, maxInFlight int32
+	results := lookupK8sAuthConfigsConcurrently(gateways, 2, func(gateway akeyless.GwClusterIdentity) (KubeAuthConfigs, error) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			observed := atomic.LoadInt32(&maxInFlight)
+			if current <= observed || atomic.CompareAndSwapInt32(&maxInFlight, observed, current) {
+				break
+			}
+		}
+
+		switch gateway.GetClusterName() {
+		case "gw-slow":
+			time.Sleep(50 * time.Millisecond)
+		case "gw-down":
+			return generateEmptyK8sAuthConfigs(), errors.New("connection refused")
+		}
+		time.Sleep(10 * time.Millisecond)
+		return KubeAuthConfigs{K8SAuths: []KubeAuthConfig{{Name: "config-of-" + gateway.GetClusterName()}}}, nil
+	})
+
+	assert.LessOrEqual(t, maxInFlight, int32(2))
+	assert.Len(t, results, len(gateways))
+	for i, result := range results {
+		if clusterNames[i] == "gw-down" {
+			assert.EqualError(t, result.Err, "connection refused")
+			continue
+		}
+		assert.NoError(t, result.Err)
+		assert.Equal(t, "config-of-"+clusterNames[i], result.KubeAuthConfigs.K8SAuths[0].Name)
+	}
+	assert.GreaterOrEqual(t, results[0].Duration, 50*time.Millisecond)
+}
+
+func TestLookupK8sAuthConfigsTimeout(t *testing.T) {
+	unresponsive := make(chan struct{})
+	gatewayServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer t-123", r.Header.Get("Authorization"))
+		<-unresponsive
+	}))
+	defer gatewayServer.Close()
+	defer close(unresponsive)
+
+	clusterUrl := gatewayServer.URL
+	_, err := lookupK8sAuthConfigs(context.Background(), http.DefaultClient, akeyless.GwClusterIdentity{ClusterUrl: &clusterUrl}, "t-123", 50*time.Millisecond)
+	assert.ErrorContains(t, err, gatewayServer.URL+"/config/k8s-auths")
+}
+
+func TestLookupK8sAuthConfigsRejectedToken(t *testing.T) {
+	gatewayServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer gatewayServer.Close()
+
+	clusterUrl := gatewayServer.URL
+	_, err := lookupK8sAuthConfigs(context.Background(), http.DefaultClient, akeyless.GwClusterIdentity{ClusterUrl: &clusterUrl}, "t-123", time.Second)
+	assert.EqualError(t, err, "unable to get k8s auth configs from "+gatewayServer.URL+"/config/k8s-auths: 401 Unauthorized")
+	assert.True(t, IsGatewayAuthError(err))
+
+	assert.False(t, IsGatewayAuthError(&GatewayStatusError{StatusCode: http.StatusBadGateway}))
+	assert.False(t, IsGatewayAuthError(errors.New("connection refused")))
+}
@@ -0,0 +1,199 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// HostResolver resolves hostnames to their addresses, it is satisfied by *net.Resolver
+type HostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// NearMiss is a k8s auth config whose K8S host points to the cluster but was rejected by the host matching
+type NearMiss struct {
+	GatewayName string `json:"gateway"`
+	GatewayUrl  string `json:"gateway_url,omitempty"`
+	Name        string `json:"name"`
+	K8sHost     string `json:"k8s_host"`
+	Reason      string `json:"reason"`
+}
+
+// defaultPort returns the port of the URL, falling back to the default port of its scheme
+func defaultPort(serverUrl *url.URL) string {
+	if port := serverUrl.Port(); len(port) > 0 {
+		return port
+	}
+	if serverUrl.Scheme == "http" {
+		return "80"
+	}
+	return "443"
+}
+
+// parseK8SHost parses the K8S host, assuming https when it has no scheme
+func parseK8SHost(k8sHost string) (*url.URL, bool) {
+	k8sHost = strings.TrimSpace(k8sHost)
+	if !strings.Contains(k8sHost, "://") {
+		k8sHost = "https://" + k8sHost
+	}
+	hostUrl, err := url.Parse(k8sHost)
+	if err != nil || len(hostUrl.Hostname()) == 0 {
+		return nil, false
+	}
+	return hostUrl, true
+}
+
+// NormalizeK8SHost returns the K8S host in a canonical form so equivalent URLs compare equal: https is assumed
+// without a scheme, the scheme and hostname are lowercased, and the default port of the scheme and the
+// trailing slashes are removed. A K8S host that does not parse as a URL is returned trimmed.
+func NormalizeK8SHost(k8sHost string) string {
+	hostUrl, ok := parseK8SHost(k8sHost)
+	if !ok {
+		return strings.TrimSpace(k8sHost)
+	}
+
+	host := strings.ToLower(hostUrl.Hostname())
+	if port := hostUrl.Port(); len(port) > 0 && port != defaultPort(&url.URL{Scheme: hostUrl.Scheme}) {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	return strings.ToLower(hostUrl.Scheme) + "://" + host + strings.TrimRight(hostUrl.EscapedPath(), "/")
+}
+
+// k8sHostUrl returns the normalized K8S host of the k8s auth config that the checks connect to, so a config
+// matched despite a missing scheme or a trailing slash is checked against the same API server
+func k8sHostUrl(kubeAuthConfig KubeAuthConfig) string {
+	return NormalizeK8SHost(kubeAuthConfig.K8SHost)
+}
+
+// differsInSchemeOrPort reports whether the K8S host points to the host of the server with another scheme
+// or port, such configs never match the cluster
+func differsInSchemeOrPort(k8sHost string, server string) bool {
+	hostUrl, err := url.Parse(k8sHost)
+	if err != nil || len(hostUrl.Hostname()) == 0 {
+		return false
+	}
+	serverUrl, err := url.Parse(server)
+	if err != nil || len(serverUrl.Hostname()) == 0 {
+		return false
+	}
+	if !strings.EqualFold(hostUrl.Hostname(), serverUrl.Hostname()) {
+		return false
+	}
+	return hostUrl.Scheme != serverUrl.Scheme || defaultPort(hostUrl) != defaultPort(serverUrl)
+}
+
+// hostMatcher matches the K8S hosts of the k8s auth configs against the server of a cluster, resolving every
+// hostname at most once
+type hostMatcher struct {
+	validator *Validator
+	server    string
+	resolved  map[string][]string
+}
+
+func newHostMatcher(v *Validator, server string) *hostMatcher {
+	return &hostMatcher{validator: v, server: server, resolved: map[string][]string{}}
+}
+
+// lookupHost returns the sorted addresses of the hostname, an IP address resolves to itself
+func (m *hostMatcher) lookupHost(ctx context.Context, hostname string) []string {
+	hostname = strings.ToLower(hostname)
+	if addresses, found := m.resolved[hostname]; found {
+		return addresses
+	}
+
+	var addresses []string
+	if ip := net.ParseIP(hostname); ip != nil {
+		addresses = []string{ip.String()}
+	} else {
+		ctx, cancel := context.WithTimeout(ctx, m.validator.Timeout)
+		defer cancel()
+		addresses, _ = m.validator.Resolver.LookupHost(ctx, hostname)
+		sort.Strings(addresses)
+	}
+	m.resolved[hostname] = addresses
+	return addresses
+}
+
+// sharesAddress reports whether the hostnames resolve to at least one common address
+func (m *hostMatcher) sharesAddress(ctx context.Context, hostname string, otherHostname string) bool {
+	otherAddresses := m.lookupHost(ctx, otherHostname)
+	for _, address := range m.lookupHost(ctx, hostname) {
+		for _, otherAddress := range otherAddresses {
+			if address == otherAddress {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// match reports whether the K8S host points to the cluster server. A K8S host that is rejected although it
+// points to the same host comes with the reason it was rejected.
+func (m *hostMatcher) match(ctx context.Context, k8sHost string) (bool, string) {
+	if NormalizeK8SHost(k8sHost) == NormalizeK8SHost(m.server) {
+		return true, ""
+	}
+
+	hostUrl, ok := parseK8SHost(k8sHost)
+	if !ok {
+		return false, ""
+	}
+	serverUrl, ok := parseK8SHost(m.server)
+	if !ok {
+		return false, ""
+	}
+
+	sameHost := "same hostname as the cluster server"
+	if !strings.EqualFold(hostUrl.Hostname(), serverUrl.Hostname()) {
+		if !m.validator.ResolveHosts || !m.sharesAddress(ctx, hostUrl.Hostname(), serverUrl.Hostname()) {
+			return false, ""
+		}
+		sameHost = fmt.Sprintf("%s resolves to the same addresses as %s (%s)", hostUrl.Hostname(), serverUrl.Hostname(), strings.Join(m.lookupHost(ctx, serverUrl.Hostname()), ","))
+	}
+
+	var differences []string
+	if !strings.EqualFold(hostUrl.Scheme, serverUrl.Scheme) {
+		differences = append(differences, fmt.Sprintf("scheme %s instead of %s", hostUrl.Scheme, serverUrl.Scheme))
+	}
+	if defaultPort(hostUrl) != defaultPort(serverUrl) {
+		differences = append(differences, fmt.Sprintf("port %s instead of %s", defaultPort(hostUrl), defaultPort(serverUrl)))
+	}
+	if hostPath, serverPath := strings.TrimRight(hostUrl.EscapedPath(), "/"), strings.TrimRight(serverUrl.EscapedPath(), "/"); hostPath != serverPath {
+		differences = append(differences, fmt.Sprintf("path %q instead of %q", hostPath, serverPath))
+	}
+	if len(differences) == 0 {
+		return true, ""
+	}
+	return false, sameHost + " but " + strings.Join(differences, ", ")
+}
+
+// NearMisses returns the k8s auth configs whose K8S host points to the host of the cluster server, or with
+// ResolveHosts to the same addresses, but with another scheme, port or path, along with why they do not match
+func (v *Validator) NearMisses(ctx context.Context, clusterDetails ClusterTarget) []NearMiss {
+	v.init()
+
+	matcher := newHostMatcher(v, clusterDetails.Server)
+	var nearMisses []NearMiss
+	for _, gatewayKubeAuthConfig := range v.gatewayKubeAuthConfigs {
+		for _, kubeAuthConfig := range gatewayKubeAuthConfig.KubeAuthConfigs.K8SAuths {
+			matches, reason := matcher.match(ctx, kubeAuthConfig.K8SHost)
+			if matches || len(reason) == 0 {
+				continue
+			}
+			nearMisses = append(nearMisses, NearMiss{
+				GatewayName: GatewayDisplayName(gatewayKubeAuthConfig),
+				GatewayUrl:  gatewayKubeAuthConfig.GwClusterIdentity.GetClusterUrl(),
+				Name:        kubeAuthConfig.Name,
+				K8sHost:     kubeAuthConfig.K8SHost,
+				Reason:      reason,
+			})
+		}
+	}
+	return nearMisses
+}
@@ -0,0 +1,93 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	akeyless "github.com/akeylesslabs/akeyless-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type testResolver map[string][]string
+
+func (r testResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if addresses, found := r[host]; found {
+		return addresses, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func TestNormalizeK8SHost(t *testing.T) {
+	for k8sHost, normalized := range map[string]string{
+		"https://1.2.3.4":                         "https://1.2.3.4",
+		"https://1.2.3.4:443":                     "https://1.2.3.4",
+		"https://1.2.3.4:6443/":                   "https://1.2.3.4:6443",
+		"HTTPS://Cluster.Example.COM/":            "https://cluster.example.com",
+		"cluster.example.com":                     "https://cluster.example.com",
+		"http://cluster.example.com:80":           "http://cluster.example.com",
+		"http://cluster.example.com:443":          "http://cluster.example.com:443",
+		"https://rancher.example.com/k8s/c-abc//": "https://rancher.example.com/k8s/c-abc",
+		"https://[::1]:443":                       "https://[::1]",
+		"https://[::1]:6443":                      "https://[::1]:6443",
+		" https://cluster.example.com ":           "https://cluster.example.com",
+	} {
+		assert.Equal(t, normalized, NormalizeK8SHost(k8sHost), k8sHost)
+	}
+}
+
+func TestHostMatcher(t *testing.T) {
+	v := New(nil, "t-123")
+	v.Resolver = testResolver{"cluster.example.com": {"10.0.0.2", "10.0.0.1"}, "api.example.com": {"10.0.0.1"}}
+	matcher := newHostMatcher(v, "https://cluster.example.com")
+
+	matches, reason := matcher.match(context.Background(), "https://CLUSTER.example.com:443/")
+	assert.True(t, matches)
+	assert.Empty(t, reason)
+
+	matches, reason = matcher.match(context.Background(), "http://cluster.example.com:6443")
+	assert.False(t, matches)
+	assert.Equal(t, "same hostname as the cluster server but scheme http instead of https, port 6443 instead of 443", reason)
+
+	matches, reason = matcher.match(context.Background(), "https://other.example.com")
+	assert.False(t, matches)
+	assert.Empty(t, reason)
+
+	// the addresses are only compared with ResolveHosts
+	matches, reason = matcher.match(context.Background(), "https://10.0.0.1")
+	assert.False(t, matches)
+	assert.Empty(t, reason)
+
+	v.ResolveHosts = true
+	matches, _ = matcher.match(context.Background(), "https://10.0.0.1")
+	assert.True(t, matches)
+	matches, _ = matcher.match(context.Background(), "https://api.example.com")
+	assert.True(t, matches)
+	matches, _ = matcher.match(context.Background(), "https://unknown.example.com")
+	assert.False(t, matches)
+
+	matches, reason = matcher.match(context.Background(), "https://api.example.com:6443")
+	assert.False(t, matches)
+	assert.Equal(t, "api.example.com resolves to the same addresses as cluster.example.com (10.0.0.1,10.0.0.2) but port 6443 instead of 443", reason)
+}
+
+func TestNearMisses(t *testing.T) {
+	v := New(nil, "t-123")
+	v.gatewayKubeAuthConfigs = []GatewayKubeAuthConfigs{{
+		GwClusterIdentity: &akeyless.GwClusterIdentity{ClusterName: akeyless.PtrString("gw-prod"), ClusterUrl: akeyless.PtrString("https://gw.example.com")},
+		KubeAuthConfigs: KubeAuthConfigs{K8SAuths: []KubeAuthConfig{
+			{Name: "matching", K8SHost: "https://cluster.example.com:443"},
+			{Name: "wrong-path", K8SHost: "https://cluster.example.com/k8s"},
+			{Name: "other-cluster", K8SHost: "https://other.example.com"},
+		}},
+	}}
+
+	nearMisses := v.NearMisses(context.Background(), ClusterTarget{Server: "https://cluster.example.com"})
+	assert.Equal(t, []NearMiss{{
+		GatewayName: "gw-prod",
+		GatewayUrl:  "https://gw.example.com",
+		Name:        "wrong-path",
+		K8sHost:     "https://cluster.example.com/k8s",
+		Reason:      `same hostname as the cluster server but path "/k8s" instead of ""`,
+	}}, nearMisses)
+}
@@ -0,0 +1,53 @@
+package validator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/client-go/rest"
+)
+
+const IN_CLUSTER_CONTEXT_NAME = "in-cluster"
+const SERVICE_ACCOUNT_PATH = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// ResolveInClusterTarget uses the pod service account and the KUBERNETES_SERVICE_HOST environment
+// variables to describe the cluster the validator is running in
+func ResolveInClusterTarget() (ClusterTarget, error) {
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return ClusterTarget{}, fmt.Errorf("unable to load the in-cluster configuration: %w", err)
+	}
+
+	return inClusterTargetFromConfig(restConfig, SERVICE_ACCOUNT_PATH)
+}
+
+// inClusterTargetFromConfig builds the cluster target from an in-cluster rest config and the
+// mounted service account directory holding the CA certificate and namespace
+func inClusterTargetFromConfig(restConfig *rest.Config, serviceAccountPath string) (ClusterTarget, error) {
+	caFile := restConfig.TLSClientConfig.CAFile
+	if len(caFile) == 0 {
+		caFile = filepath.Join(serviceAccountPath, "ca.crt")
+	}
+
+	caData, err := os.ReadFile(caFile)
+	if err != nil {
+		return ClusterTarget{}, fmt.Errorf("unable to read the service account CA certificate: %w", err)
+	}
+
+	// the namespace file is optional so an empty namespace is reported when it cannot be read
+	namespace, _ := os.ReadFile(filepath.Join(serviceAccountPath, "namespace"))
+
+	return ClusterTarget{
+		ContextName:              IN_CLUSTER_CONTEXT_NAME,
+		ContextSource:            serviceAccountPath,
+		ClusterName:              IN_CLUSTER_CONTEXT_NAME,
+		Namespace:                strings.TrimSpace(string(namespace)),
+		AuthInfo:                 "serviceaccount",
+		Server:                   restConfig.Host,
+		CertificateAuthorityFile: caFile,
+		CertificateAuthorityData: caData,
+		RestConfig:               restConfig,
+	}, nil
+}
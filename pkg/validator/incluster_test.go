@@ -0,0 +1,27 @@
+package validator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/rest"
+)
+
+func TestInClusterTargetFromConfig(t *testing.T) {
+	serviceAccountPath := t.TempDir()
+	caData := []byte("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n")
+	assert.NoError(t, os.WriteFile(filepath.Join(serviceAccountPath, "ca.crt"), caData, 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(serviceAccountPath, "namespace"), []byte("akeyless\n"), 0600))
+
+	target, err := inClusterTargetFromConfig(&rest.Config{Host: "https://10.96.0.1:443"}, serviceAccountPath)
+	assert.NoError(t, err)
+	assert.Equal(t, IN_CLUSTER_CONTEXT_NAME, target.ContextName)
+	assert.Equal(t, "https://10.96.0.1:443", target.Server)
+	assert.Equal(t, "akeyless", target.Namespace)
+	assert.Equal(t, caData, target.CertificateAuthorityData)
+
+	_, err = inClusterTargetFromConfig(&rest.Config{Host: "https://10.96.0.1:443"}, t.TempDir())
+	assert.Error(t, err)
+}
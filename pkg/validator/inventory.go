@@ -0,0 +1,163 @@
+package validator
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+type InventoryStatus string
+
+// INVENTORY_MATCHES_CLUSTER configs match a validated cluster
+const INVENTORY_MATCHES_CLUSTER InventoryStatus = "matches-cluster"
+
+// INVENTORY_MATCHES_CONTEXT configs match the cluster of a kubeconfig context that was not validated
+const INVENTORY_MATCHES_CONTEXT InventoryStatus = "matches-context"
+
+// INVENTORY_ORPHANED configs match no known cluster, usually left behind after a cluster was rebuilt
+const INVENTORY_ORPHANED InventoryStatus = "orphaned"
+
+// MAX_INVENTORY_SUGGESTIONS is the number of "did you mean" suggestions of an orphaned config
+const MAX_INVENTORY_SUGGESTIONS = 3
+
+// InventorySuggestion is a known cluster an orphaned config might have been meant for
+type InventorySuggestion struct {
+	Context string `json:"context"`
+	Server  string `json:"server"`
+	Reason  string `json:"reason"`
+
+	distance int
+}
+
+// InventoryEntry is a k8s auth config of a gateway along with the known clusters it matches
+type InventoryEntry struct {
+	GatewayName string          `json:"gateway"`
+	GatewayUrl  string          `json:"gateway_url,omitempty"`
+	Name        string          `json:"name"`
+	K8sHost     string          `json:"k8s_host"`
+	Status      InventoryStatus `json:"status"`
+	// Contexts are the kubeconfig contexts of the clusters the config matches
+	Contexts    []string              `json:"contexts,omitempty"`
+	Suggestions []InventorySuggestion `json:"suggestions,omitempty"`
+}
+
+// levenshteinDistance returns the number of single character edits turning a into b
+func levenshteinDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func minInt(values ...int) int {
+	minimum := values[0]
+	for _, value := range values[1:] {
+		if value < minimum {
+			minimum = value
+		}
+	}
+	return minimum
+}
+
+// similarK8SHosts reports whether the normalized hosts are a few typos apart, at most a quarter of their length
+func similarK8SHosts(k8sHost string, server string) (int, bool) {
+	normalizedHost := NormalizeK8SHost(k8sHost)
+	normalizedServer := NormalizeK8SHost(server)
+	distance := levenshteinDistance(normalizedHost, normalizedServer)
+	return distance, distance <= len(normalizedServer)/4
+}
+
+// Inventory lists every k8s auth config of every loaded gateway, marking each as matching one of the validated
+// clusters, matching the cluster of another known kubeconfig context, or orphaned. Orphaned configs come with
+// the known clusters they might have been meant for, either pointing to the same host with another scheme,
+// port or path, or with a similar K8S host.
+func (v *Validator) Inventory(ctx context.Context, validatedClusters []ClusterTarget, knownClusters []ClusterTarget) []InventoryEntry {
+	v.init()
+
+	validated := make(map[string]bool, len(validatedClusters))
+	for _, clusterDetails := range validatedClusters {
+		validated[clusterDetails.ContextName] = true
+	}
+	// the validated clusters are known even when they are not part of the kubeconfig, like in-cluster
+	clusters := append([]ClusterTarget{}, validatedClusters...)
+	for _, clusterDetails := range knownClusters {
+		if !validated[clusterDetails.ContextName] {
+			clusters = append(clusters, clusterDetails)
+		}
+	}
+	matchers := make([]*hostMatcher, 0, len(clusters))
+	for _, clusterDetails := range clusters {
+		matchers = append(matchers, newHostMatcher(v, clusterDetails.Server))
+	}
+
+	var entries []InventoryEntry
+	for _, gatewayKubeAuthConfig := range v.gatewayKubeAuthConfigs {
+		for _, kubeAuthConfig := range gatewayKubeAuthConfig.KubeAuthConfigs.K8SAuths {
+			entry := InventoryEntry{
+				GatewayName: GatewayDisplayName(gatewayKubeAuthConfig),
+				GatewayUrl:  gatewayKubeAuthConfig.GwClusterIdentity.GetClusterUrl(),
+				Name:        kubeAuthConfig.Name,
+				K8sHost:     kubeAuthConfig.K8SHost,
+				Status:      INVENTORY_ORPHANED,
+			}
+
+			var suggestions []InventorySuggestion
+			for i, clusterDetails := range clusters {
+				matches, reason := matchers[i].match(ctx, kubeAuthConfig.K8SHost)
+				if matches {
+					entry.Contexts = append(entry.Contexts, clusterDetails.ContextName)
+					if validated[clusterDetails.ContextName] {
+						entry.Status = INVENTORY_MATCHES_CLUSTER
+					} else if entry.Status == INVENTORY_ORPHANED {
+						entry.Status = INVENTORY_MATCHES_CONTEXT
+					}
+					continue
+				}
+
+				suggestion := InventorySuggestion{Context: clusterDetails.ContextName, Server: clusterDetails.Server, Reason: reason}
+				if len(reason) == 0 {
+					distance, similar := similarK8SHosts(kubeAuthConfig.K8SHost, clusterDetails.Server)
+					if !similar {
+						continue
+					}
+					suggestion.Reason = "similar K8S host"
+					suggestion.distance = distance
+				}
+				suggestions = append(suggestions, suggestion)
+			}
+
+			if entry.Status == INVENTORY_ORPHANED {
+				// the configs pointing to the same host come first, then the most similar hosts
+				sort.SliceStable(suggestions, func(i, j int) bool {
+					return suggestions[i].distance < suggestions[j].distance
+				})
+				if len(suggestions) > MAX_INVENTORY_SUGGESTIONS {
+					suggestions = suggestions[:MAX_INVENTORY_SUGGESTIONS]
+				}
+				entry.Suggestions = suggestions
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].GatewayName != entries[j].GatewayName {
+			return entries[i].GatewayName < entries[j].GatewayName
+		}
+		return strings.ToLower(entries[i].Name) < strings.ToLower(entries[j].Name)
+	})
+	return entries
+}
@@ -0,0 +1,53 @@
+package validator
+
+import (
+	"context"
+	"testing"
+
+	akeyless "github.com/akeylesslabs/akeyless-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevenshteinDistance(t *testing.T) {
+	assert.Equal(t, 0, levenshteinDistance("cluster", "cluster"))
+	assert.Equal(t, 3, levenshteinDistance("", "abc"))
+	assert.Equal(t, 1, levenshteinDistance("prod-eks", "prod-eks2"))
+	assert.Equal(t, 3, levenshteinDistance("kitten", "sitting"))
+}
+
+func TestInventory(t *testing.T) {
+	v := New(nil, "t-123")
+	v.gatewayKubeAuthConfigs = []GatewayKubeAuthConfigs{
+		{
+			GwClusterIdentity: &akeyless.GwClusterIdentity{ClusterName: akeyless.PtrString("gw-prod")},
+			KubeAuthConfigs: KubeAuthConfigs{K8SAuths: []KubeAuthConfig{
+				{Name: "prod", K8SHost: "https://prod.example.com:443"},
+				{Name: "prod-wrong-port", K8SHost: "https://prod.example.com:6443"},
+				{Name: "prod-old", K8SHost: "https://prod-old.example.com"},
+			}},
+		},
+		{
+			GwClusterIdentity: &akeyless.GwClusterIdentity{ClusterName: akeyless.PtrString("gw-dev")},
+			KubeAuthConfigs: KubeAuthConfigs{K8SAuths: []KubeAuthConfig{
+				{Name: "dev", K8SHost: "https://dev.example.com"},
+				{Name: "gone", K8SHost: "https://10.20.30.40"},
+			}},
+		},
+	}
+
+	prod := ClusterTarget{ContextName: "prod", Server: "https://prod.example.com"}
+	dev := ClusterTarget{ContextName: "dev", Server: "https://dev.example.com"}
+	inventory := v.Inventory(context.Background(), []ClusterTarget{prod}, []ClusterTarget{prod, dev})
+
+	assert.Equal(t, []InventoryEntry{
+		{GatewayName: "gw-dev", Name: "dev", K8sHost: "https://dev.example.com", Status: INVENTORY_MATCHES_CONTEXT, Contexts: []string{"dev"}},
+		{GatewayName: "gw-dev", Name: "gone", K8sHost: "https://10.20.30.40", Status: INVENTORY_ORPHANED},
+		{GatewayName: "gw-prod", Name: "prod", K8sHost: "https://prod.example.com:443", Status: INVENTORY_MATCHES_CLUSTER, Contexts: []string{"prod"}},
+		{GatewayName: "gw-prod", Name: "prod-old", K8sHost: "https://prod-old.example.com", Status: INVENTORY_ORPHANED, Suggestions: []InventorySuggestion{
+			{Context: "prod", Server: "https://prod.example.com", Reason: "similar K8S host", distance: 4},
+		}},
+		{GatewayName: "gw-prod", Name: "prod-wrong-port", K8sHost: "https://prod.example.com:6443", Status: INVENTORY_ORPHANED, Suggestions: []InventorySuggestion{
+			{Context: "prod", Server: "https://prod.example.com", Reason: "same hostname as the cluster server but port 6443 instead of 443"},
+		}},
+	}, inventory)
+}
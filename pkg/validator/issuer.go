@@ -0,0 +1,77 @@
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const CHECK_ISSUER = "issuer"
+const DEFAULT_K8S_ISSUER = "kubernetes/serviceaccount"
+const OIDC_DISCOVERY_PATH = "/.well-known/openid-configuration"
+
+// OIDCDiscovery holds the fields of the cluster service account issuer discovery document
+type OIDCDiscovery struct {
+	Issuer  string `json:"issuer"`
+	JwksURI string `json:"jwks_uri"`
+}
+
+// getFromApiServer performs a raw GET on the kubernetes API server path with the given credentials
+func getFromApiServer(ctx context.Context, restConfig *rest.Config, path string) ([]byte, error) {
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := clientset.Discovery().RESTClient().Get().AbsPath(path).DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get %s from the API server: %w", path, err)
+	}
+	return body, nil
+}
+
+// fetchOIDCDiscovery fetches the service account issuer discovery document of the cluster
+func fetchOIDCDiscovery(ctx context.Context, restConfig *rest.Config) (OIDCDiscovery, error) {
+	var discovery OIDCDiscovery
+
+	body, err := getFromApiServer(ctx, restConfig, OIDC_DISCOVERY_PATH)
+	if err != nil {
+		return discovery, err
+	}
+	if err := json.Unmarshal(body, &discovery); err != nil {
+		return discovery, fmt.Errorf("unable to parse the OIDC discovery document: %w", err)
+	}
+	if len(discovery.Issuer) == 0 {
+		return discovery, fmt.Errorf("the OIDC discovery document does not advertise an issuer")
+	}
+
+	return discovery, nil
+}
+
+// compareIssuer compares the k8s auth config issuer settings with the issuer advertised by the cluster
+func compareIssuer(kubeAuthConfig KubeAuthConfig, advertisedIssuer string) (CheckStatus, string) {
+	configuredIssuer := kubeAuthConfig.K8SIssuer
+	if len(configuredIssuer) == 0 {
+		configuredIssuer = DEFAULT_K8S_ISSUER
+	}
+
+	if kubeAuthConfig.DisableIssValidation {
+		if configuredIssuer == advertisedIssuer || len(kubeAuthConfig.K8SIssuer) == 0 {
+			return CHECK_STATUS_WARN, fmt.Sprintf("Issuer validation is disabled unnecessarily, the cluster advertises the issuer %q, set it as the K8S issuer and enable issuer validation", advertisedIssuer)
+		}
+		return CHECK_STATUS_WARN, fmt.Sprintf("Issuer validation is disabled and the K8S issuer %q does not match the cluster issuer %q", configuredIssuer, advertisedIssuer)
+	}
+
+	if configuredIssuer == advertisedIssuer {
+		return CHECK_STATUS_PASS, fmt.Sprintf("K8S issuer matches the cluster issuer %q", advertisedIssuer)
+	}
+
+	message := fmt.Sprintf("K8S issuer %q does not match the cluster issuer %q, service account tokens will be rejected with an iss mismatch", configuredIssuer, advertisedIssuer)
+	if len(kubeAuthConfig.K8SIssuer) == 0 {
+		message = fmt.Sprintf("K8S issuer is not set so the default %q is expected but the cluster issuer is %q, service account tokens will be rejected with an iss mismatch", DEFAULT_K8S_ISSUER, advertisedIssuer)
+	}
+	return CHECK_STATUS_FAIL, message
+}
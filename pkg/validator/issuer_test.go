@@ -0,0 +1,49 @@
+package validator
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/rest"
+)
+
+func TestFetchOIDCDiscovery(t *testing.T) {
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != OIDC_DISCOVERY_PATH {
+			http.NotFound(w, r)
+			return
+		}
+		assert.Equal(t, "Bearer kubeconfig-token", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"issuer": "https://oidc.eks.us-east-1.amazonaws.com/id/ABC", "jwks_uri": "https://10.0.0.1/openid/v1/jwks"}`))
+	}))
+	defer apiServer.Close()
+
+	discovery, err := fetchOIDCDiscovery(context.Background(), &rest.Config{Host: apiServer.URL, BearerToken: "kubeconfig-token"})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://oidc.eks.us-east-1.amazonaws.com/id/ABC", discovery.Issuer)
+}
+
+func TestCompareIssuer(t *testing.T) {
+	eksIssuer := "https://oidc.eks.us-east-1.amazonaws.com/id/ABC"
+
+	status, _ := compareIssuer(KubeAuthConfig{K8SIssuer: eksIssuer}, eksIssuer)
+	assert.Equal(t, CHECK_STATUS_PASS, status)
+
+	status, _ = compareIssuer(KubeAuthConfig{}, DEFAULT_K8S_ISSUER)
+	assert.Equal(t, CHECK_STATUS_PASS, status)
+
+	status, message := compareIssuer(KubeAuthConfig{}, eksIssuer)
+	assert.Equal(t, CHECK_STATUS_FAIL, status)
+	assert.Contains(t, message, "iss mismatch")
+
+	status, _ = compareIssuer(KubeAuthConfig{K8SIssuer: "https://container.googleapis.com/v1/projects/p/locations/l/clusters/c"}, eksIssuer)
+	assert.Equal(t, CHECK_STATUS_FAIL, status)
+
+	status, message = compareIssuer(KubeAuthConfig{DisableIssValidation: true}, eksIssuer)
+	assert.Equal(t, CHECK_STATUS_WARN, status)
+	assert.Contains(t, message, "disabled unnecessarily")
+}
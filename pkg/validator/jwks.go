@@ -0,0 +1,228 @@
+package validator
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"k8s.io/client-go/rest"
+)
+
+const CHECK_PUB_KEYS = "pub-keys"
+const JWKS_PATH = "/openid/v1/jwks"
+
+// JSONWebKey holds the fields of an RSA or EC JSON web key
+type JSONWebKey struct {
+	KeyID string `json:"kid"`
+	Kty   string `json:"kty"`
+	N     string `json:"n,omitempty"`
+	E     string `json:"e,omitempty"`
+	Crv   string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// ServiceAccountSigningKey is a public key able to verify service account tokens
+type ServiceAccountSigningKey struct {
+	KeyID       string
+	Fingerprint string
+	PEM         string
+}
+
+// PubKeysComparison holds the result of comparing the cluster signing keys with the k8s auth config public keys
+type PubKeysComparison struct {
+	Matching []ServiceAccountSigningKey
+	// Missing keys are used by the cluster but not configured in the k8s auth config
+	Missing []ServiceAccountSigningKey
+	// Stale keys are configured in the k8s auth config but no longer used by the cluster
+	Stale []ServiceAccountSigningKey
+}
+
+// fetchServiceAccountJWKS fetches the service account signing keys of the cluster
+func fetchServiceAccountJWKS(ctx context.Context, restConfig *rest.Config) (JSONWebKeySet, error) {
+	var jwks JSONWebKeySet
+
+	body, err := getFromApiServer(ctx, restConfig, JWKS_PATH)
+	if err != nil {
+		return jwks, err
+	}
+	if err := json.Unmarshal(body, &jwks); err != nil {
+		return jwks, fmt.Errorf("unable to parse the service account JWKS: %w", err)
+	}
+
+	return jwks, nil
+}
+
+func decodeBase64URLInt(value string) (*big.Int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(decoded), nil
+}
+
+// publicKey converts the JSON web key into a crypto public key
+func (k JSONWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		modulus, err := decodeBase64URLInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus of key %q: %w", k.KeyID, err)
+		}
+		exponent, err := decodeBase64URLInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA exponent of key %q: %w", k.KeyID, err)
+		}
+		return &rsa.PublicKey{N: modulus, E: int(exponent.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q of key %q", k.Crv, k.KeyID)
+		}
+		x, err := decodeBase64URLInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC x coordinate of key %q: %w", k.KeyID, err)
+		}
+		y, err := decodeBase64URLInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC y coordinate of key %q: %w", k.KeyID, err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q of key %q", k.Kty, k.KeyID)
+	}
+}
+
+// newSigningKey marshals the public key and computes the fingerprint used to compare keys
+func newSigningKey(keyID string, publicKey interface{}) (ServiceAccountSigningKey, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return ServiceAccountSigningKey{}, err
+	}
+	fingerprint := sha256.Sum256(der)
+
+	return ServiceAccountSigningKey{
+		KeyID:       keyID,
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+		PEM:         string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	}, nil
+}
+
+// signingKeysFromJWKS converts every key of the JWKS into a PEM encoded signing key
+func signingKeysFromJWKS(jwks JSONWebKeySet) ([]ServiceAccountSigningKey, error) {
+	signingKeys := make([]ServiceAccountSigningKey, 0, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		publicKey, err := jwk.publicKey()
+		if err != nil {
+			return nil, err
+		}
+		signingKey, err := newSigningKey(jwk.KeyID, publicKey)
+		if err != nil {
+			return nil, err
+		}
+		signingKeys = append(signingKeys, signingKey)
+	}
+	return signingKeys, nil
+}
+
+// parsePublicKeysPem parses every public key of the k8s auth config public keys, which may be base64 encoded
+func parsePublicKeysPem(k8sPubKeysPem string) ([]ServiceAccountSigningKey, error) {
+	data := []byte(strings.TrimSpace(k8sPubKeysPem))
+	if !strings.Contains(string(data), "-----BEGIN") {
+		decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(data)), ""))
+		if err != nil {
+			return nil, fmt.Errorf("the k8s auth config public keys are neither PEM nor base64 encoded PEM")
+		}
+		data = decoded
+	}
+
+	var signingKeys []ServiceAccountSigningKey
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		var publicKey interface{}
+		var err error
+		switch block.Type {
+		case "PUBLIC KEY":
+			publicKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			publicKey, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		case "CERTIFICATE":
+			var certificate *x509.Certificate
+			certificate, err = x509.ParseCertificate(block.Bytes)
+			if err == nil {
+				publicKey = certificate.PublicKey
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse the k8s auth config public key: %w", err)
+		}
+
+		signingKey, err := newSigningKey("", publicKey)
+		if err != nil {
+			return nil, err
+		}
+		signingKeys = append(signingKeys, signingKey)
+	}
+
+	if len(signingKeys) == 0 {
+		return nil, fmt.Errorf("no public keys found in the k8s auth config public keys")
+	}
+	return signingKeys, nil
+}
+
+// comparePublicKeys compares the cluster signing keys with the k8s auth config public keys by fingerprint
+func comparePublicKeys(clusterKeys []ServiceAccountSigningKey, configKeys []ServiceAccountSigningKey) PubKeysComparison {
+	var comparison PubKeysComparison
+
+	configFingerprints := make(map[string]bool, len(configKeys))
+	for _, configKey := range configKeys {
+		configFingerprints[configKey.Fingerprint] = true
+	}
+
+	clusterFingerprints := make(map[string]bool, len(clusterKeys))
+	for _, clusterKey := range clusterKeys {
+		clusterFingerprints[clusterKey.Fingerprint] = true
+		if configFingerprints[clusterKey.Fingerprint] {
+			comparison.Matching = append(comparison.Matching, clusterKey)
+		} else {
+			comparison.Missing = append(comparison.Missing, clusterKey)
+		}
+	}
+
+	for _, configKey := range configKeys {
+		if !clusterFingerprints[configKey.Fingerprint] {
+			comparison.Stale = append(comparison.Stale, configKey)
+		}
+	}
+
+	return comparison
+}
@@ -0,0 +1,76 @@
+package validator
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/rest"
+)
+
+func encodeBase64URLInt(value *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(value.Bytes())
+}
+
+func publicKeyPEM(t *testing.T, publicKey interface{}) string {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	assert.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestComparePublicKeysWithJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	rotatedKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	jwks := JSONWebKeySet{Keys: []JSONWebKey{
+		{KeyID: "rsa-current", Kty: "RSA", N: encodeBase64URLInt(rsaKey.N), E: encodeBase64URLInt(big.NewInt(int64(rsaKey.E)))},
+		{KeyID: "ec-new", Kty: "EC", Crv: "P-256", X: encodeBase64URLInt(ecKey.X), Y: encodeBase64URLInt(ecKey.Y)},
+	}}
+
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != JWKS_PATH {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jwks)
+	}))
+	defer apiServer.Close()
+
+	fetchedJwks, err := fetchServiceAccountJWKS(context.Background(), &rest.Config{Host: apiServer.URL})
+	assert.NoError(t, err)
+	clusterKeys, err := signingKeysFromJWKS(fetchedJwks)
+	assert.NoError(t, err)
+	assert.Len(t, clusterKeys, 2)
+	assert.Equal(t, publicKeyPEM(t, &rsaKey.PublicKey), clusterKeys[0].PEM)
+
+	// the config still holds the rotated key and only one of the current keys, base64 encoded
+	configPem := publicKeyPEM(t, &rsaKey.PublicKey) + publicKeyPEM(t, &rotatedKey.PublicKey)
+	configKeys, err := parsePublicKeysPem(base64.StdEncoding.EncodeToString([]byte(configPem)))
+	assert.NoError(t, err)
+
+	comparison := comparePublicKeys(clusterKeys, configKeys)
+	assert.Len(t, comparison.Matching, 1)
+	assert.Equal(t, "rsa-current", comparison.Matching[0].KeyID)
+	assert.Len(t, comparison.Missing, 1)
+	assert.Equal(t, "ec-new", comparison.Missing[0].KeyID)
+	assert.Len(t, comparison.Stale, 1)
+
+	_, err = parsePublicKeysPem("not a key")
+	assert.Error(t, err)
+}
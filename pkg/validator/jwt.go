@@ -0,0 +1,135 @@
+package validator
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const CHECK_REVIEWER_JWT = "reviewer-jwt"
+
+const TOKEN_TYPE_LEGACY = "legacy secret-based token"
+const TOKEN_TYPE_BOUND = "bound projected token"
+
+// JWTAudience accepts the aud claim as either a single string or a list of strings
+type JWTAudience []string
+
+func (a *JWTAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = JWTAudience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = JWTAudience(multiple)
+	return nil
+}
+
+type ServiceAccountReference struct {
+	Name string `json:"name,omitempty"`
+	UID  string `json:"uid,omitempty"`
+}
+
+// KubernetesClaims are the claims kubernetes adds to bound service account tokens
+type KubernetesClaims struct {
+	Namespace      string                   `json:"namespace,omitempty"`
+	ServiceAccount ServiceAccountReference  `json:"serviceaccount,omitempty"`
+	Pod            *ServiceAccountReference `json:"pod,omitempty"`
+	Secret         *ServiceAccountReference `json:"secret,omitempty"`
+}
+
+// ServiceAccountTokenClaims holds the claims of both legacy and bound service account tokens
+type ServiceAccountTokenClaims struct {
+	Issuer                  string            `json:"iss,omitempty"`
+	Subject                 string            `json:"sub,omitempty"`
+	Audiences               JWTAudience       `json:"aud,omitempty"`
+	IssuedAt                int64             `json:"iat,omitempty"`
+	NotBefore               int64             `json:"nbf,omitempty"`
+	Expiry                  int64             `json:"exp,omitempty"`
+	Kubernetes              *KubernetesClaims `json:"kubernetes.io,omitempty"`
+	LegacyNamespace         string            `json:"kubernetes.io/serviceaccount/namespace,omitempty"`
+	LegacySecretName        string            `json:"kubernetes.io/serviceaccount/secret.name,omitempty"`
+	LegacyServiceAccount    string            `json:"kubernetes.io/serviceaccount/service-account.name,omitempty"`
+	LegacyServiceAccountUID string            `json:"kubernetes.io/serviceaccount/service-account.uid,omitempty"`
+}
+
+// decodeJWTClaims decodes the claims of a JWT without verifying its signature
+func decodeJWTClaims(token string) (ServiceAccountTokenClaims, error) {
+	var claims ServiceAccountTokenClaims
+
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return claims, fmt.Errorf("the token is not a JWT, expected 3 parts but found %d", len(parts))
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return claims, fmt.Errorf("unable to decode the JWT payload: %w", err)
+	}
+
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, fmt.Errorf("unable to parse the JWT claims: %w", err)
+	}
+
+	return claims, nil
+}
+
+// TokenType returns whether the token is a legacy secret-based token or a bound projected token
+func (c ServiceAccountTokenClaims) TokenType() string {
+	if c.Kubernetes != nil {
+		return TOKEN_TYPE_BOUND
+	}
+	return TOKEN_TYPE_LEGACY
+}
+
+// ServiceAccount returns the namespace and name of the service account the token belongs to
+func (c ServiceAccountTokenClaims) ServiceAccount() (string, string) {
+	if c.Kubernetes != nil {
+		return c.Kubernetes.Namespace, c.Kubernetes.ServiceAccount.Name
+	}
+	if len(c.LegacyNamespace) > 0 {
+		return c.LegacyNamespace, c.LegacyServiceAccount
+	}
+
+	// fall back to the subject which has the format system:serviceaccount:<namespace>:<name>
+	subjectParts := strings.Split(c.Subject, ":")
+	if len(subjectParts) == 4 && subjectParts[0] == "system" && subjectParts[1] == "serviceaccount" {
+		return subjectParts[2], subjectParts[3]
+	}
+	return "", ""
+}
+
+// ExpiresAt returns the expiry time of the token, or a zero time if the token never expires
+func (c ServiceAccountTokenClaims) ExpiresAt() time.Time {
+	if c.Expiry == 0 {
+		return time.Time{}
+	}
+	return time.Unix(c.Expiry, 0)
+}
+
+// lintReviewerJWT decodes the token reviewer JWT and returns the check status and message, warning
+// when the token expires within the expiry warning window
+func lintReviewerJWT(claims ServiceAccountTokenClaims, now time.Time, expiryWarning time.Duration) (CheckStatus, string) {
+	expiresAt := claims.ExpiresAt()
+
+	switch {
+	case !expiresAt.IsZero() && !expiresAt.After(now):
+		return CHECK_STATUS_FAIL, fmt.Sprintf("Token Reviewer JWT expired at %s", expiresAt.Format(time.RFC3339))
+	case claims.NotBefore > 0 && time.Unix(claims.NotBefore, 0).After(now):
+		return CHECK_STATUS_FAIL, fmt.Sprintf("Token Reviewer JWT is not valid before %s", time.Unix(claims.NotBefore, 0).Format(time.RFC3339))
+	case !expiresAt.IsZero() && expiresAt.Sub(now) <= expiryWarning:
+		return CHECK_STATUS_WARN, fmt.Sprintf("Token Reviewer JWT expires in %s at %s", expiresAt.Sub(now).Round(time.Minute), expiresAt.Format(time.RFC3339))
+	case !expiresAt.IsZero() && claims.Kubernetes != nil && claims.Kubernetes.Pod != nil:
+		return CHECK_STATUS_WARN, fmt.Sprintf("Token Reviewer JWT is bound to pod %s and stops working when the pod is deleted", claims.Kubernetes.Pod.Name)
+	case expiresAt.IsZero():
+		return CHECK_STATUS_PASS, "Token Reviewer JWT never expires"
+	default:
+		return CHECK_STATUS_PASS, fmt.Sprintf("Token Reviewer JWT expires in %s", expiresAt.Sub(now).Round(time.Minute))
+	}
+}
@@ -0,0 +1,97 @@
+package validator
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// buildTestJWT returns an unsigned JWT carrying the given claims
+func buildTestJWT(t *testing.T, claims map[string]interface{}) string {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","kid":"test"}`))
+	return header + "." + base64.RawURLEncoding.EncodeToString(payload) + ".c2lnbmF0dXJl"
+}
+
+func TestDecodeJWTClaims(t *testing.T) {
+	t.Run("Legacy secret-based token", func(t *testing.T) {
+		claims, err := decodeJWTClaims(buildTestJWT(t, map[string]interface{}{
+			"iss":                                    "kubernetes/serviceaccount",
+			"sub":                                    "system:serviceaccount:akeyless:reviewer",
+			"kubernetes.io/serviceaccount/namespace": "akeyless",
+			"kubernetes.io/serviceaccount/secret.name":          "reviewer-token",
+			"kubernetes.io/serviceaccount/service-account.name": "reviewer",
+		}))
+		assert.NoError(t, err)
+		assert.Equal(t, TOKEN_TYPE_LEGACY, claims.TokenType())
+		namespace, serviceAccount := claims.ServiceAccount()
+		assert.Equal(t, "akeyless", namespace)
+		assert.Equal(t, "reviewer", serviceAccount)
+		assert.True(t, claims.ExpiresAt().IsZero())
+	})
+
+	t.Run("Bound projected token", func(t *testing.T) {
+		claims, err := decodeJWTClaims(buildTestJWT(t, map[string]interface{}{
+			"iss": "https://oidc.eks.amazonaws.com/id/ABC",
+			"sub": "system:serviceaccount:akeyless:reviewer",
+			"aud": []string{"https://kubernetes.default.svc", "sts.amazonaws.com"},
+			"exp": 1700000000,
+			"iat": 1690000000,
+			"kubernetes.io": map[string]interface{}{
+				"namespace":      "akeyless",
+				"serviceaccount": map[string]string{"name": "reviewer", "uid": "1234"},
+			},
+		}))
+		assert.NoError(t, err)
+		assert.Equal(t, TOKEN_TYPE_BOUND, claims.TokenType())
+		assert.Equal(t, JWTAudience{"https://kubernetes.default.svc", "sts.amazonaws.com"}, claims.Audiences)
+		assert.Equal(t, time.Unix(1700000000, 0), claims.ExpiresAt())
+	})
+
+	t.Run("Single audience and subject fallback", func(t *testing.T) {
+		claims, err := decodeJWTClaims(buildTestJWT(t, map[string]interface{}{
+			"sub": "system:serviceaccount:default:token-reviewer",
+			"aud": "vault",
+		}))
+		assert.NoError(t, err)
+		assert.Equal(t, JWTAudience{"vault"}, claims.Audiences)
+		namespace, serviceAccount := claims.ServiceAccount()
+		assert.Equal(t, "default", namespace)
+		assert.Equal(t, "token-reviewer", serviceAccount)
+	})
+
+	t.Run("Not a JWT", func(t *testing.T) {
+		_, err := decodeJWTClaims("not-a-jwt")
+		assert.Error(t, err)
+	})
+}
+
+func TestLintReviewerJWT(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	week := 7 * 24 * time.Hour
+
+	status, _ := lintReviewerJWT(ServiceAccountTokenClaims{}, now, week)
+	assert.Equal(t, CHECK_STATUS_PASS, status)
+
+	status, _ = lintReviewerJWT(ServiceAccountTokenClaims{Expiry: now.Add(30 * 24 * time.Hour).Unix()}, now, week)
+	assert.Equal(t, CHECK_STATUS_PASS, status)
+
+	status, message := lintReviewerJWT(ServiceAccountTokenClaims{Expiry: now.Add(48 * time.Hour).Unix()}, now, week)
+	assert.Equal(t, CHECK_STATUS_WARN, status)
+	assert.Contains(t, message, "48h0m0s")
+
+	status, _ = lintReviewerJWT(ServiceAccountTokenClaims{Expiry: now.Add(-time.Minute).Unix()}, now, week)
+	assert.Equal(t, CHECK_STATUS_FAIL, status)
+
+	status, _ = lintReviewerJWT(ServiceAccountTokenClaims{
+		Expiry:     now.Add(30 * 24 * time.Hour).Unix(),
+		Kubernetes: &KubernetesClaims{Pod: &ServiceAccountReference{Name: "gateway-0"}},
+	}, now, week)
+	assert.Equal(t, CHECK_STATUS_WARN, status)
+}
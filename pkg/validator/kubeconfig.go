@@ -0,0 +1,116 @@
+package validator
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// ClusterTarget holds the details of the kubernetes cluster that is being validated
+type ClusterTarget struct {
+	ContextName              string
+	ContextSource            string
+	ClusterName              string
+	Namespace                string
+	AuthInfo                 string
+	Server                   string
+	CertificateAuthorityFile string
+	CertificateAuthorityData []byte
+	// RestConfig holds the credentials of the kubeconfig user (or pod service account) for the cluster
+	RestConfig *rest.Config
+}
+
+// NewKubeconfigLoadingRules returns the standard clientcmd loading rules so that the KUBECONFIG
+// merge chain and ~/.kube/config are honored, unless an explicit kubeconfig path is provided
+func NewKubeconfigLoadingRules(kubeconfigPath string) *clientcmd.ClientConfigLoadingRules {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if len(kubeconfigPath) > 0 {
+		loadingRules.ExplicitPath = kubeconfigPath
+	}
+	return loadingRules
+}
+
+// DescribeKubeconfigSource returns the kubeconfig file(s) that the loading rules will read from
+func DescribeKubeconfigSource(loadingRules *clientcmd.ClientConfigLoadingRules) string {
+	if len(loadingRules.ExplicitPath) > 0 {
+		return loadingRules.ExplicitPath
+	}
+	return strings.Join(loadingRules.GetLoadingPrecedence(), string(os.PathListSeparator))
+}
+
+// ResolveClusterTarget looks up the context (or the current context when none is given) in the
+// merged kubeconfig and returns the details of the cluster it points to
+func ResolveClusterTarget(config *clientcmdapi.Config, contextName string) (ClusterTarget, error) {
+	if len(contextName) == 0 {
+		contextName = config.CurrentContext
+	}
+	if len(contextName) == 0 {
+		return ClusterTarget{}, fmt.Errorf("no context was provided and the kubeconfig has no current context set")
+	}
+
+	contextDetails, ok := config.Contexts[contextName]
+	if !ok {
+		return ClusterTarget{}, fmt.Errorf("context %q was not found in the kubeconfig", contextName)
+	}
+
+	clusterDetails, ok := config.Clusters[contextDetails.Cluster]
+	if !ok {
+		return ClusterTarget{}, fmt.Errorf("cluster %q referenced by context %q was not found in the kubeconfig", contextDetails.Cluster, contextName)
+	}
+
+	// kubeconfigs can reference the CA by file path instead of inlining the certificate data
+	certificateAuthorityData := clusterDetails.CertificateAuthorityData
+	if len(certificateAuthorityData) == 0 && len(clusterDetails.CertificateAuthority) > 0 {
+		var err error
+		certificateAuthorityData, err = os.ReadFile(clusterDetails.CertificateAuthority)
+		if err != nil {
+			return ClusterTarget{}, fmt.Errorf("unable to read the certificate-authority file of cluster %q: %w", contextDetails.Cluster, err)
+		}
+	}
+
+	restConfig, err := clientcmd.NewNonInteractiveClientConfig(*config, contextName, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+	if err != nil {
+		return ClusterTarget{}, fmt.Errorf("unable to build the client configuration of context %q: %w", contextName, err)
+	}
+
+	return ClusterTarget{
+		ContextName:              contextName,
+		ContextSource:            contextDetails.LocationOfOrigin,
+		ClusterName:              contextDetails.Cluster,
+		Namespace:                contextDetails.Namespace,
+		AuthInfo:                 contextDetails.AuthInfo,
+		Server:                   clusterDetails.Server,
+		CertificateAuthorityFile: clusterDetails.CertificateAuthority,
+		CertificateAuthorityData: certificateAuthorityData,
+		RestConfig:               restConfig,
+	}, nil
+}
+
+// ListContextNames returns the sorted names of every context in the kubeconfig, limited to the
+// contexts matching the regular expression when one is given
+func ListContextNames(config *clientcmdapi.Config, contextRegex string) ([]string, error) {
+	var contextPattern *regexp.Regexp
+	if len(contextRegex) > 0 {
+		var err error
+		contextPattern, err = regexp.Compile(contextRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid context regex %q: %w", contextRegex, err)
+		}
+	}
+
+	contextNames := make([]string, 0, len(config.Contexts))
+	for contextName := range config.Contexts {
+		if contextPattern == nil || contextPattern.MatchString(contextName) {
+			contextNames = append(contextNames, contextName)
+		}
+	}
+	sort.Strings(contextNames)
+
+	return contextNames, nil
+}
@@ -0,0 +1,103 @@
+package validator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testKubeconfigA = `apiVersion: v1
+kind: Config
+current-context: ctx-a
+clusters:
+- name: cluster-a
+  cluster:
+    server: https://a.example.com
+contexts:
+- name: ctx-a
+  context:
+    cluster: cluster-a
+    namespace: ns-a
+    user: user-a
+users:
+- name: user-a
+  user:
+    token: token-a
+`
+
+const testKubeconfigB = `apiVersion: v1
+kind: Config
+current-context: ctx-b
+clusters:
+- name: cluster-b
+  cluster:
+    server: https://b.example.com
+contexts:
+- name: ctx-b
+  context:
+    cluster: cluster-b
+    user: user-b
+users:
+- name: user-b
+  user:
+    token: token-b
+`
+
+func writeTestKubeconfig(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestResolveClusterTarget(t *testing.T) {
+	pathA := writeTestKubeconfig(t, "a.yaml", testKubeconfigA)
+	pathB := writeTestKubeconfig(t, "b.yaml", testKubeconfigB)
+
+	t.Run("Explicit kubeconfig uses its current context", func(t *testing.T) {
+		loadingRules := NewKubeconfigLoadingRules(pathB)
+		assert.Equal(t, pathB, DescribeKubeconfigSource(loadingRules))
+
+		config, err := loadingRules.Load()
+		assert.NoError(t, err)
+
+		target, err := ResolveClusterTarget(config, "")
+		assert.NoError(t, err)
+		assert.Equal(t, "ctx-b", target.ContextName)
+		assert.Equal(t, "https://b.example.com", target.Server)
+		assert.Equal(t, pathB, target.ContextSource)
+	})
+
+	t.Run("KUBECONFIG merge chain with context override", func(t *testing.T) {
+		t.Setenv("KUBECONFIG", pathA+string(os.PathListSeparator)+pathB)
+		loadingRules := NewKubeconfigLoadingRules("")
+		assert.Equal(t, pathA+string(os.PathListSeparator)+pathB, DescribeKubeconfigSource(loadingRules))
+
+		config, err := loadingRules.Load()
+		assert.NoError(t, err)
+
+		// the first file in the chain wins for the current context
+		target, err := ResolveClusterTarget(config, "")
+		assert.NoError(t, err)
+		assert.Equal(t, "ctx-a", target.ContextName)
+		assert.Equal(t, "ns-a", target.Namespace)
+		assert.Equal(t, "user-a", target.AuthInfo)
+
+		target, err = ResolveClusterTarget(config, "ctx-b")
+		assert.NoError(t, err)
+		assert.Equal(t, "cluster-b", target.ClusterName)
+		assert.Equal(t, "https://b.example.com", target.Server)
+		assert.Equal(t, pathB, target.ContextSource)
+	})
+
+	t.Run("Unknown context", func(t *testing.T) {
+		config, err := NewKubeconfigLoadingRules(pathA).Load()
+		assert.NoError(t, err)
+
+		_, err = ResolveClusterTarget(config, "missing")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,94 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const CHECK_REVIEWER_RBAC = "reviewer-rbac"
+const AUTH_DELEGATOR_CLUSTER_ROLE = "system:auth-delegator"
+
+// newReviewerRestConfig returns a rest config that authenticates as the token reviewer JWT of the k8s
+// auth config and trusts the k8s auth config CA certificate, just like the gateway
+func newReviewerRestConfig(kubeAuthConfig KubeAuthConfig, timeout time.Duration) (*rest.Config, error) {
+	restConfig := &rest.Config{
+		Host:        k8sHostUrl(kubeAuthConfig),
+		BearerToken: kubeAuthConfig.K8STokenReviewerJwt,
+		Timeout:     timeout,
+	}
+
+	if len(kubeAuthConfig.K8SCaCert) > 0 {
+		caData, err := decodeK8SCaCert(kubeAuthConfig.K8SCaCert)
+		if err != nil {
+			return nil, err
+		}
+		restConfig.TLSClientConfig.CAData = caData
+	}
+
+	return restConfig, nil
+}
+
+// canCreateTokenReviews asks the API server, as the token reviewer, whether it may create tokenreviews
+func canCreateTokenReviews(ctx context.Context, reviewerClientset kubernetes.Interface) (bool, string, error) {
+	selfSubjectAccessReview := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Group:    "authentication.k8s.io",
+				Resource: "tokenreviews",
+				Verb:     "create",
+			},
+		},
+	}
+
+	response, err := reviewerClientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, selfSubjectAccessReview, metav1.CreateOptions{})
+	if err != nil {
+		return false, "", fmt.Errorf("unable to create a SelfSubjectAccessReview as the token reviewer: %w", err)
+	}
+
+	return response.Status.Allowed, response.Status.Reason, nil
+}
+
+// subjectMatchesServiceAccount reports whether the binding subject grants access to the service account
+func subjectMatchesServiceAccount(subject rbacv1.Subject, namespace string, serviceAccount string) bool {
+	switch subject.Kind {
+	case rbacv1.ServiceAccountKind:
+		return subject.Namespace == namespace && subject.Name == serviceAccount
+	case rbacv1.UserKind:
+		return subject.Name == "system:serviceaccount:"+namespace+":"+serviceAccount
+	case rbacv1.GroupKind:
+		return subject.Name == "system:serviceaccounts" || subject.Name == "system:serviceaccounts:"+namespace || subject.Name == "system:authenticated"
+	default:
+		return false
+	}
+}
+
+// findAuthDelegatorBindings returns the names of the ClusterRoleBindings granting system:auth-delegator
+// to the service account
+func findAuthDelegatorBindings(ctx context.Context, clientset kubernetes.Interface, namespace string, serviceAccount string) ([]string, error) {
+	clusterRoleBindings, err := clientset.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list ClusterRoleBindings: %w", err)
+	}
+
+	var bindingNames []string
+	for _, clusterRoleBinding := range clusterRoleBindings.Items {
+		if clusterRoleBinding.RoleRef.Kind != "ClusterRole" || clusterRoleBinding.RoleRef.Name != AUTH_DELEGATOR_CLUSTER_ROLE {
+			continue
+		}
+		for _, subject := range clusterRoleBinding.Subjects {
+			if subjectMatchesServiceAccount(subject, namespace, serviceAccount) {
+				bindingNames = append(bindingNames, clusterRoleBinding.Name)
+				break
+			}
+		}
+	}
+
+	return bindingNames, nil
+}
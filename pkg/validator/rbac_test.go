@@ -0,0 +1,60 @@
+package validator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestCanCreateTokenReviews(t *testing.T) {
+	for _, allowed := range []bool{true, false} {
+		clientset := fake.NewSimpleClientset()
+		clientset.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+			assert.Equal(t, "authentication.k8s.io", review.Spec.ResourceAttributes.Group)
+			assert.Equal(t, "tokenreviews", review.Spec.ResourceAttributes.Resource)
+			assert.Equal(t, "create", review.Spec.ResourceAttributes.Verb)
+			review.Status.Allowed = allowed
+			return true, review, nil
+		})
+
+		result, _, err := canCreateTokenReviews(context.Background(), clientset)
+		assert.NoError(t, err)
+		assert.Equal(t, allowed, result)
+	}
+}
+
+func TestFindAuthDelegatorBindings(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "akeyless-token-reviewer"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: AUTH_DELEGATOR_CLUSTER_ROLE},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Namespace: "akeyless", Name: "reviewer"}},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-namespace"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: AUTH_DELEGATOR_CLUSTER_ROLE},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Namespace: "default", Name: "reviewer"}},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Namespace: "akeyless", Name: "reviewer"}},
+		},
+	)
+
+	bindingNames, err := findAuthDelegatorBindings(context.Background(), clientset, "akeyless", "reviewer")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"akeyless-token-reviewer"}, bindingNames)
+
+	bindingNames, err = findAuthDelegatorBindings(context.Background(), clientset, "akeyless", "someone-else")
+	assert.NoError(t, err)
+	assert.Empty(t, bindingNames)
+}
@@ -0,0 +1,168 @@
+package validator
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const DEFAULT_REVIEWER_NAMESPACE = "akeyless"
+const DEFAULT_REVIEWER_SERVICE_ACCOUNT = "gateway-token-reviewer"
+
+// Remediator is implemented by the checks that can suggest how to fix a result that does not pass
+type Remediator interface {
+	// Remediation returns ready to run commands fixing the result, or an empty string when there is none
+	Remediation(target CheckTarget, result CheckResult) string
+}
+
+// shellQuote quotes the value for a POSIX shell
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'"'"'`) + "'"
+}
+
+// gatewayUpdateFields are the k8s auth config fields set by the gateway-update-k8s-auth-config remediation,
+// the current value of the config is kept for every field left empty
+type gatewayUpdateFields struct {
+	K8SHost   string
+	K8SCaCert string
+	K8SIssuer string
+	// TokenReviewerJwt is a shell expression, the token reviewer JWT is never printed
+	TokenReviewerJwt string
+	// EnableIssuerValidation turns the issuer validation back on, it is kept as is otherwise
+	EnableIssuerValidation bool
+}
+
+// gatewayUpdateCommand returns the akeyless CLI command updating the k8s auth config on its gateway. The
+// unchanged settings, including the token expiration, issuer validation, cluster API type and protection key
+// the CLI would otherwise reset to their defaults, are repeated so the update does not reset them, the secrets
+// are left as variables.
+func gatewayUpdateCommand(target CheckTarget, fields gatewayUpdateFields) string {
+	kubeAuthConfig := target.KubeAuthConfig
+	if len(fields.K8SHost) == 0 {
+		fields.K8SHost = kubeAuthConfig.K8SHost
+	}
+	if len(fields.K8SCaCert) == 0 {
+		fields.K8SCaCert = kubeAuthConfig.K8SCaCert
+	}
+	if len(fields.K8SIssuer) == 0 {
+		fields.K8SIssuer = kubeAuthConfig.K8SIssuer
+	}
+	if len(fields.TokenReviewerJwt) == 0 && len(kubeAuthConfig.K8STokenReviewerJwt) > 0 {
+		fields.TokenReviewerJwt = `"$TOKEN_REVIEWER_JWT"`
+	}
+
+	args := []string{
+		"--gateway-url " + shellQuote(target.GatewayUrl),
+		"--name " + shellQuote(kubeAuthConfig.Name) + " --new-name " + shellQuote(kubeAuthConfig.Name),
+		"--access-id " + shellQuote(kubeAuthConfig.AuthMethodAccessID) + ` --signing-key "$SIGNING_KEY"`,
+		"--k8s-host " + shellQuote(fields.K8SHost),
+	}
+	if len(fields.K8SCaCert) > 0 {
+		args = append(args, "--k8s-ca-cert "+shellQuote(fields.K8SCaCert))
+	}
+	if len(fields.K8SIssuer) > 0 {
+		args = append(args, "--k8s-issuer "+shellQuote(fields.K8SIssuer))
+	}
+	if len(fields.TokenReviewerJwt) > 0 {
+		args = append(args, "--token-reviewer-jwt "+fields.TokenReviewerJwt)
+	}
+	args = append(args, "--disable-issuer-validation "+strconv.FormatBool(kubeAuthConfig.DisableIssValidation && !fields.EnableIssuerValidation))
+	if len(kubeAuthConfig.ClusterAPIType) > 0 {
+		args = append(args, "--cluster-api-type "+shellQuote(kubeAuthConfig.ClusterAPIType))
+	}
+	if kubeAuthConfig.AmTokenExpiration > 0 {
+		args = append(args, "--token-exp "+strconv.Itoa(kubeAuthConfig.AmTokenExpiration))
+	}
+	if len(kubeAuthConfig.ProtectionKey) > 0 {
+		args = append(args, "--config-encryption-key-name "+shellQuote(kubeAuthConfig.ProtectionKey))
+	}
+
+	return "# SIGNING_KEY is the private key of the auth method " + kubeAuthConfig.AuthMethodAccessID + "\n" +
+		"akeyless gateway-update-k8s-auth-config \\\n  " + strings.Join(args, " \\\n  ")
+}
+
+// kubectlCommand returns the kubectl command run against the context of the cluster target
+func kubectlCommand(cluster ClusterTarget, args string) string {
+	if len(cluster.ContextName) > 0 && cluster.ContextName != IN_CLUSTER_CONTEXT_NAME {
+		return "kubectl --context " + shellQuote(cluster.ContextName) + " " + args
+	}
+	return "kubectl " + args
+}
+
+// remediateCaCertificate updates the k8s auth config with the CA certificate of the kubeconfig
+func remediateCaCertificate(target CheckTarget, result CheckResult) string {
+	if len(target.Cluster.CertificateAuthorityData) == 0 {
+		return ""
+	}
+	return gatewayUpdateCommand(target, gatewayUpdateFields{
+		K8SCaCert: base64.StdEncoding.EncodeToString(target.Cluster.CertificateAuthorityData),
+	})
+}
+
+// remediateIssuer updates the k8s auth config with the issuer advertised by the cluster and turns the issuer
+// validation back on, which is only safe once the issuer matches
+func remediateIssuer(target CheckTarget, result CheckResult) string {
+	clusterIssuer := result.Evidence["cluster_issuer"]
+	if len(clusterIssuer) == 0 {
+		return ""
+	}
+	return gatewayUpdateCommand(target, gatewayUpdateFields{K8SIssuer: clusterIssuer, EnableIssuerValidation: true})
+}
+
+// reviewerServiceAccount returns the service account of the token reviewer JWT, falling back to the default
+// token reviewer service account when the JWT could not be decoded
+func reviewerServiceAccount(target CheckTarget) (string, string) {
+	for _, check := range target.Checks {
+		if check.Name != CHECK_REVIEWER_JWT {
+			continue
+		}
+		namespace, serviceAccount, found := strings.Cut(check.Evidence["service_account"], "/")
+		if found && len(namespace) > 0 && len(serviceAccount) > 0 {
+			return namespace, serviceAccount
+		}
+	}
+	return DEFAULT_REVIEWER_NAMESPACE, DEFAULT_REVIEWER_SERVICE_ACCOUNT
+}
+
+// authDelegatorBindingCommand returns the kubectl command binding the service account to system:auth-delegator
+func authDelegatorBindingCommand(cluster ClusterTarget, namespace string, serviceAccount string) string {
+	return kubectlCommand(cluster, fmt.Sprintf("create clusterrolebinding %s --clusterrole=%s --serviceaccount=%s --dry-run=client -o yaml | %s",
+		shellQuote(serviceAccount+"-auth-delegator"), AUTH_DELEGATOR_CLUSTER_ROLE, shellQuote(namespace+":"+serviceAccount), kubectlCommand(cluster, "apply -f -")))
+}
+
+// remediateReviewerJWT creates a token reviewer service account bound to system:auth-delegator along with a
+// long-lived token, and updates the k8s auth config with that token
+func remediateReviewerJWT(target CheckTarget, result CheckResult) string {
+	namespace, serviceAccount := reviewerServiceAccount(target)
+	secretName := reviewerTokenSecretName(serviceAccount)
+
+	lines := []string{
+		kubectlCommand(target.Cluster, fmt.Sprintf("create serviceaccount %s -n %s --dry-run=client -o yaml | %s",
+			shellQuote(serviceAccount), shellQuote(namespace), kubectlCommand(target.Cluster, "apply -f -"))),
+		authDelegatorBindingCommand(target.Cluster, namespace, serviceAccount),
+		kubectlCommand(target.Cluster, "apply -f - <<EOF"),
+		"apiVersion: v1",
+		"kind: Secret",
+		"metadata:",
+		"  name: " + secretName,
+		"  namespace: " + namespace,
+		"  annotations:",
+		"    kubernetes.io/service-account.name: " + serviceAccount,
+		"type: kubernetes.io/service-account-token",
+		"EOF",
+		"TOKEN_REVIEWER_JWT=$(" + kubectlCommand(target.Cluster, fmt.Sprintf("get secret %s -n %s -o jsonpath='{.data.token}'", shellQuote(secretName), shellQuote(namespace))) + " | base64 -d)",
+		gatewayUpdateCommand(target, gatewayUpdateFields{TokenReviewerJwt: `"$TOKEN_REVIEWER_JWT"`}),
+	}
+	return strings.Join(lines, "\n")
+}
+
+// remediateReviewerRBAC binds the token reviewer service account to system:auth-delegator
+func remediateReviewerRBAC(target CheckTarget, result CheckResult) string {
+	reviewerUsername, _ := target.reviewerUsername()
+	namespace, serviceAccount := ServiceAccountTokenClaims{Subject: reviewerUsername}.ServiceAccount()
+	if len(serviceAccount) == 0 {
+		return ""
+	}
+	return authDelegatorBindingCommand(target.Cluster, namespace, serviceAccount)
+}
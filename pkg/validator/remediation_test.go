@@ -0,0 +1,110 @@
+package validator
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCheckTarget() CheckTarget {
+	return CheckTarget{
+		Finding: Finding{
+			GatewayName: "gw-prod",
+			GatewayUrl:  "https://gw.example.com",
+			KubeAuthConfig: KubeAuthConfig{
+				Name:                "k8s-eks",
+				AuthMethodAccessID:  "p-1234",
+				K8SHost:             "https://cluster.example.com",
+				K8SCaCert:           "b2xk",
+				K8STokenReviewerJwt: "secret-jwt",
+				ClusterAPIType:      "native_k8s",
+				AmTokenExpiration:   3600,
+			},
+		},
+		Cluster: ClusterTarget{
+			ContextName:              "prod-eks",
+			Server:                   "https://cluster.example.com",
+			CertificateAuthorityData: []byte("new-ca"),
+		},
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, `'it'"'"'s'`, shellQuote("it's"))
+}
+
+func TestRemediateCaCertificate(t *testing.T) {
+	remediation := remediateCaCertificate(newTestCheckTarget(), CheckResult{})
+	assert.Equal(t, `# SIGNING_KEY is the private key of the auth method p-1234
+akeyless gateway-update-k8s-auth-config \
+  --gateway-url 'https://gw.example.com' \
+  --name 'k8s-eks' --new-name 'k8s-eks' \
+  --access-id 'p-1234' --signing-key "$SIGNING_KEY" \
+  --k8s-host 'https://cluster.example.com' \
+  --k8s-ca-cert '`+base64.StdEncoding.EncodeToString([]byte("new-ca"))+`' \
+  --token-reviewer-jwt "$TOKEN_REVIEWER_JWT" \
+  --disable-issuer-validation false \
+  --cluster-api-type 'native_k8s' \
+  --token-exp 3600`, remediation)
+	assert.NotContains(t, remediation, "secret-jwt")
+
+	// the disabled issuer validation and the protection key are kept as is
+	target := newTestCheckTarget()
+	target.KubeAuthConfig.DisableIssValidation = true
+	target.KubeAuthConfig.ProtectionKey = "customer-key"
+	remediation = remediateCaCertificate(target, CheckResult{})
+	assert.Contains(t, remediation, "--disable-issuer-validation true")
+	assert.Contains(t, remediation, "--config-encryption-key-name 'customer-key'")
+
+	target = newTestCheckTarget()
+	target.Cluster.CertificateAuthorityData = nil
+	assert.Empty(t, remediateCaCertificate(target, CheckResult{}))
+}
+
+func TestRemediateIssuer(t *testing.T) {
+	// issuer validation disabled unnecessarily is fixed by setting the cluster issuer and enabling it again
+	target := newTestCheckTarget()
+	target.KubeAuthConfig.DisableIssValidation = true
+	remediation := remediateIssuer(target, CheckResult{Evidence: map[string]string{"cluster_issuer": "https://oidc.example.com"}})
+	assert.Contains(t, remediation, "--k8s-issuer 'https://oidc.example.com'")
+	assert.Contains(t, remediation, "--disable-issuer-validation false")
+
+	assert.Empty(t, remediateIssuer(target, CheckResult{}))
+}
+
+func TestRemediateReviewerJWT(t *testing.T) {
+	target := newTestCheckTarget()
+	remediation := remediateReviewerJWT(target, CheckResult{})
+	assert.Contains(t, remediation, "kubectl --context 'prod-eks' create serviceaccount 'gateway-token-reviewer' -n 'akeyless'")
+	assert.Contains(t, remediation, "--clusterrole=system:auth-delegator --serviceaccount='akeyless:gateway-token-reviewer'")
+	assert.Contains(t, remediation, "kubernetes.io/service-account.name: gateway-token-reviewer")
+	assert.Contains(t, remediation, `--token-reviewer-jwt "$TOKEN_REVIEWER_JWT"`)
+	assert.NotContains(t, remediation, "secret-jwt")
+
+	// the service account of the current token reviewer JWT is reused
+	target.Checks = []CheckResult{{Name: CHECK_REVIEWER_JWT, Status: CHECK_STATUS_FAIL, Evidence: map[string]string{"service_account": "kube-system/reviewer"}}}
+	target.Cluster.ContextName = IN_CLUSTER_CONTEXT_NAME
+	assert.Contains(t, remediateReviewerJWT(target, CheckResult{}), "kubectl create serviceaccount 'reviewer' -n 'kube-system'")
+}
+
+func TestRemediateReviewerRBAC(t *testing.T) {
+	target := newTestCheckTarget()
+	target.Checks = []CheckResult{{Name: CHECK_TOKEN_REVIEWER, Status: CHECK_STATUS_PASS, Evidence: map[string]string{"username": "system:serviceaccount:akeyless:reviewer"}}}
+	assert.Equal(t, "kubectl --context 'prod-eks' create clusterrolebinding 'reviewer-auth-delegator' --clusterrole=system:auth-delegator --serviceaccount='akeyless:reviewer' --dry-run=client -o yaml | kubectl --context 'prod-eks' apply -f -", remediateReviewerRBAC(target, CheckResult{}))
+}
+
+func TestRunCheckRemediation(t *testing.T) {
+	check := builtinCheck{id: CHECK_ISSUER, severity: SEVERITY_ERROR, run: func(v *Validator, ctx context.Context, target CheckTarget) CheckResult {
+		return newCheckResult(CHECK_ISSUER, CHECK_STATUS_FAIL, "issuer does not match", map[string]string{"cluster_issuer": "https://issuer.example.com"})
+	}, remediate: remediateIssuer}
+	result := runCheck(context.Background(), check, newTestCheckTarget())
+	assert.Contains(t, result.Remediation, "--k8s-issuer 'https://issuer.example.com'")
+
+	// passing checks have no remediation
+	check.run = func(v *Validator, ctx context.Context, target CheckTarget) CheckResult {
+		return newCheckResult(CHECK_ISSUER, CHECK_STATUS_PASS, "issuer matches", map[string]string{"cluster_issuer": "https://issuer.example.com"})
+	}
+	assert.Empty(t, runCheck(context.Background(), check, newTestCheckTarget()).Remediation)
+}
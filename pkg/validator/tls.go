@@ -0,0 +1,131 @@
+package validator
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+)
+
+const CHECK_TLS = "tls-verify"
+
+// newKubeAuthConfigTLSConfig returns the TLS configuration the gateway uses to call the kubernetes API
+// server, trusting only the k8s auth config CA certificate or the system roots when none is set
+func newKubeAuthConfigTLSConfig(kubeAuthConfig KubeAuthConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+	if len(kubeAuthConfig.K8SCaCert) == 0 {
+		return tlsConfig, nil
+	}
+
+	caData, err := decodeK8SCaCert(kubeAuthConfig.K8SCaCert)
+	if err != nil {
+		return nil, err
+	}
+	certificates, err := parseCertificateBundle(caData)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the k8s auth config CA certificate: %w", err)
+	}
+
+	rootCAs := x509.NewCertPool()
+	for _, certificate := range certificates {
+		rootCAs.AddCert(certificate)
+	}
+	tlsConfig.RootCAs = rootCAs
+
+	return tlsConfig, nil
+}
+
+// hostAndPort returns the host name and port of the kubernetes API server url, defaulting to port 443
+func hostAndPort(k8sHost string) (string, string, error) {
+	parsedUrl, err := url.Parse(k8sHost)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid kubernetes host %q: %w", k8sHost, err)
+	}
+	if parsedUrl.Scheme != "https" {
+		return "", "", fmt.Errorf("kubernetes host %q does not use https", k8sHost)
+	}
+
+	port := parsedUrl.Port()
+	if len(port) == 0 {
+		port = "443"
+	}
+	return parsedUrl.Hostname(), port, nil
+}
+
+// verifyApiServerTLS connects to the kubernetes API server and verifies the serving certificate chain
+// the same way the gateway does, returning the serving certificate and a descriptive error on failure
+func verifyApiServerTLS(ctx context.Context, kubeAuthConfig KubeAuthConfig) (*x509.Certificate, error) {
+	hostname, port, err := hostAndPort(k8sHostUrl(kubeAuthConfig))
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := newKubeAuthConfigTLSConfig(kubeAuthConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// The handshake skips verification so the serving certificate can be inspected and the failure
+	// reason reported precisely, the chain is verified right after against the configured roots
+	dialer := &tls.Dialer{Config: &tls.Config{
+		ServerName:         hostname,
+		InsecureSkipVerify: true,
+	}}
+	connection, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(hostname, port))
+	if err != nil {
+		return nil, fmt.Errorf("unable to complete a TLS handshake with %s: %w", kubeAuthConfig.K8SHost, err)
+	}
+	defer connection.Close()
+
+	peerCertificates := connection.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(peerCertificates) == 0 {
+		return nil, fmt.Errorf("the API server did not present a certificate")
+	}
+	servingCertificate := peerCertificates[0]
+
+	intermediates := x509.NewCertPool()
+	for _, certificate := range peerCertificates[1:] {
+		intermediates.AddCert(certificate)
+	}
+
+	_, err = servingCertificate.Verify(x509.VerifyOptions{
+		DNSName:       hostname,
+		Roots:         tlsConfig.RootCAs,
+		Intermediates: intermediates,
+		CurrentTime:   time.Now(),
+	})
+	if err != nil {
+		return servingCertificate, errors.New(describeCertificateVerificationError(err))
+	}
+
+	return servingCertificate, nil
+}
+
+// describeCertificateVerificationError explains why the serving certificate failed verification
+func describeCertificateVerificationError(err error) string {
+	var hostnameError x509.HostnameError
+	var invalidError x509.CertificateInvalidError
+	var unknownAuthorityError x509.UnknownAuthorityError
+
+	switch {
+	case errors.As(err, &hostnameError):
+		return "hostname/SAN mismatch: " + hostnameError.Error()
+	case errors.As(err, &invalidError) && invalidError.Reason == x509.Expired:
+		return fmt.Sprintf("certificate %q is expired or not yet valid (valid from %s until %s)",
+			invalidError.Cert.Subject.String(),
+			invalidError.Cert.NotBefore.Format(time.RFC3339),
+			invalidError.Cert.NotAfter.Format(time.RFC3339))
+	case errors.As(err, &unknownAuthorityError):
+		issuer := "unknown issuer"
+		if unknownAuthorityError.Cert != nil {
+			issuer = unknownAuthorityError.Cert.Issuer.String()
+		}
+		return fmt.Sprintf("serving certificate is signed by %q which is not in the k8s auth config CA certificate", issuer)
+	default:
+		return err.Error()
+	}
+}
@@ -0,0 +1,100 @@
+package validator
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// startTestApiServer starts a TLS server whose serving certificate is signed by a freshly generated
+// CA, returning the server and the base64 encoded CA certificate
+func startTestApiServer(t *testing.T, notAfter time.Time) (*httptest.Server, string) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-kubernetes-ca"},
+		NotBefore:             time.Now().Add(-48 * time.Hour),
+		NotAfter:              time.Now().Add(48 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	assert.NoError(t, err)
+	caCertificate, err := x509.ParseCertificate(caDer)
+	assert.NoError(t, err)
+
+	servingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	servingTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "kube-apiserver"},
+		NotBefore:    time.Now().Add(-48 * time.Hour),
+		NotAfter:     notAfter,
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	servingDer, err := x509.CreateCertificate(rand.Reader, servingTemplate, caCertificate, &servingKey.PublicKey, caKey)
+	assert.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{servingDer}, PrivateKey: servingKey}},
+	}
+	server.StartTLS()
+
+	caPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDer})
+	return server, base64.StdEncoding.EncodeToString(caPem)
+}
+
+func TestVerifyApiServerTLS(t *testing.T) {
+	server, caCert := startTestApiServer(t, time.Now().Add(24*time.Hour))
+	defer server.Close()
+
+	t.Run("Trusted chain", func(t *testing.T) {
+		servingCertificate, err := verifyApiServerTLS(context.Background(), KubeAuthConfig{K8SHost: server.URL, K8SCaCert: caCert})
+		assert.NoError(t, err)
+		assert.Equal(t, "kube-apiserver", servingCertificate.Subject.CommonName)
+	})
+
+	t.Run("Unknown authority", func(t *testing.T) {
+		otherCa := base64.StdEncoding.EncodeToString(generateTestCertificatePEM(t, "other"))
+		_, err := verifyApiServerTLS(context.Background(), KubeAuthConfig{K8SHost: server.URL, K8SCaCert: otherCa})
+		assert.ErrorContains(t, err, "not in the k8s auth config CA certificate")
+	})
+
+	t.Run("Hostname mismatch", func(t *testing.T) {
+		localhostUrl := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+		_, err := verifyApiServerTLS(context.Background(), KubeAuthConfig{K8SHost: localhostUrl, K8SCaCert: caCert})
+		assert.ErrorContains(t, err, "hostname/SAN mismatch")
+	})
+
+	t.Run("Expired serving certificate", func(t *testing.T) {
+		expiredServer, expiredCaCert := startTestApiServer(t, time.Now().Add(-time.Hour))
+		defer expiredServer.Close()
+
+		_, err := verifyApiServerTLS(context.Background(), KubeAuthConfig{K8SHost: expiredServer.URL, K8SCaCert: expiredCaCert})
+		assert.ErrorContains(t, err, "expired")
+	})
+
+	t.Run("Plain http host", func(t *testing.T) {
+		_, err := verifyApiServerTLS(context.Background(), KubeAuthConfig{K8SHost: "http://127.0.0.1:6443"})
+		assert.ErrorContains(t, err, "does not use https")
+	})
+}
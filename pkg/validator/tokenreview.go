@@ -0,0 +1,119 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/gojek/heimdall/httpclient"
+)
+
+type TokenReviewPayload struct {
+	Kind       string `json:"kind,omitempty"`
+	APIVersion string `json:"apiVersion,omitempty"`
+	Spec       Spec   `json:"spec,omitempty"`
+}
+
+type TokenReviewResponse struct {
+	Kind       string   `json:"kind,omitempty"`
+	APIVersion string   `json:"apiVersion,omitempty"`
+	Metadata   Metadata `json:"metadata,omitempty"`
+	Spec       Spec     `json:"spec,omitempty"`
+	Status     Status   `json:"status,omitempty"`
+}
+type Metadata struct {
+	CreationTimestamp interface{} `json:"creationTimestamp,omitempty"`
+}
+type Spec struct {
+	Token string `json:"token,omitempty"`
+}
+type Extra struct {
+	AuthenticationKubernetesIoPodName []string `json:"authentication.kubernetes.io/pod-name,omitempty"`
+	AuthenticationKubernetesIoPodUID  []string `json:"authentication.kubernetes.io/pod-uid,omitempty"`
+}
+type User struct {
+	Username string   `json:"username,omitempty"`
+	UID      string   `json:"uid,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+	Extra    Extra    `json:"extra,omitempty"`
+}
+type Status struct {
+	Authenticated bool     `json:"authenticated,omitempty"`
+	User          User     `json:"user,omitempty"`
+	Audiences     []string `json:"audiences,omitempty"`
+}
+
+// lookupTokenReviewerStatus creates a TokenReview of the token reviewer JWT on the API server, authenticated
+// as the token reviewer itself, the same way the gateway validates service account tokens
+func lookupTokenReviewerStatus(url string, kubeAuthConfig KubeAuthConfig, timeout time.Duration) (TokenReviewResponse, error) {
+	var tokenReviewResponse TokenReviewResponse
+
+	// Trust the k8s auth config CA certificate the same way the gateway does when calling the API server.
+	tlsConfig, err := newKubeAuthConfigTLSConfig(kubeAuthConfig)
+	if err != nil {
+		return tokenReviewResponse, err
+	}
+	customClient := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+		},
+	}
+
+	// Create a new HTTP client with a default timeout and the custom client.
+	client := httpclient.NewClient(
+		httpclient.WithHTTPTimeout(timeout),
+		httpclient.WithHTTPClient(customClient),
+	)
+
+	var tokenReviewerJwt string = kubeAuthConfig.K8STokenReviewerJwt
+
+	// Create an instance of the Spec struct.
+	tokenReviewSpec := Spec{
+		Token: tokenReviewerJwt,
+	}
+	// Create an instance of the Payload struct.
+	payload := TokenReviewPayload{
+		Kind:       "TokenReview",
+		APIVersion: "authentication.k8s.io/v1",
+		Spec:       tokenReviewSpec,
+	}
+
+	// Use the json.Marshal function to convert the Payload struct to JSON.
+	payloadJson, err := json.Marshal(payload)
+	if err != nil {
+		return tokenReviewResponse, err
+	}
+
+	// Convert payloadJson to io.Reader type
+	payloadReader := bytes.NewBuffer(payloadJson)
+
+	// Define the HTTP headers.
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/json")
+	headers.Set("Accept", "application/json")
+	headers.Set("Authorization", "Bearer "+kubeAuthConfig.K8STokenReviewerJwt)
+
+	// Make the POST request.
+	response, err2 := client.Post(url, payloadReader, headers)
+	if err2 != nil {
+		return tokenReviewResponse, fmt.Errorf("unable to call the token review endpoint %s: %w", url, err2)
+	}
+	defer response.Body.Close()
+
+	// deserialize the response body into a byte array
+	body, err3 := ioutil.ReadAll(response.Body)
+	if err3 != nil {
+		return tokenReviewResponse, fmt.Errorf("unable to read the token review response: %w", err3)
+	}
+
+	// deserialize the response body into a TokenReviewResponse struct
+	err4 := json.Unmarshal(body, &tokenReviewResponse)
+	if err4 != nil {
+		return tokenReviewResponse, fmt.Errorf("unable to parse the token review response: %w", err4)
+	}
+
+	return tokenReviewResponse, nil
+}
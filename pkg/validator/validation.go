@@ -0,0 +1,637 @@
+package validator
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/logrusorgru/aurora/v4"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+type CheckStatus string
+
+const CHECK_STATUS_PASS CheckStatus = "PASS"
+const CHECK_STATUS_WARN CheckStatus = "WARN"
+const CHECK_STATUS_FAIL CheckStatus = "FAIL"
+
+const CHECK_CA_CERT = "ca-cert"
+const CHECK_TOKEN_REVIEWER = "token-reviewer"
+
+// CheckResult is the outcome of a single check against a matched k8s auth config
+type CheckResult struct {
+	Name     string            `json:"name"`
+	Status   CheckStatus       `json:"status"`
+	Message  string            `json:"message"`
+	Evidence map[string]string `json:"evidence,omitempty"`
+	// Remediation holds ready to run commands fixing a check that does not pass, when one is known
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// Finding holds the results of all checks run against a gateway k8s auth config matching the cluster
+type Finding struct {
+	GatewayName    string
+	GatewayUrl     string
+	KubeAuthConfig KubeAuthConfig
+	Checks         []CheckResult
+}
+
+// newCheckResult returns the outcome of a check along with the values it was based on
+func newCheckResult(name string, status CheckStatus, message string, evidence map[string]string) CheckResult {
+	return CheckResult{
+		Name:     name,
+		Status:   status,
+		Message:  message,
+		Evidence: evidence,
+	}
+}
+
+// StatusOf returns the status of the named check, or an empty status if the check did not run
+func (c Finding) StatusOf(name string) CheckStatus {
+	for _, check := range c.Checks {
+		if check.Name == name {
+			return check.Status
+		}
+	}
+	return ""
+}
+
+// GatewayDisplayName returns the display name of the gateway cluster falling back to the cluster name
+func GatewayDisplayName(gatewayKubeAuthConfig GatewayKubeAuthConfigs) string {
+	displayName := gatewayKubeAuthConfig.GwClusterIdentity.GetDisplayName()
+	if len(displayName) > 0 {
+		return displayName
+	}
+	return gatewayKubeAuthConfig.GwClusterIdentity.GetClusterName()
+}
+
+// Run compares the cluster endpoint against every gateway k8s auth config and runs the checks on each
+// config that matches. The gateways are listed with the Akeyless client unless LoadGateways was called.
+// An empty list of findings means no gateway k8s auth config matches the cluster.
+func (v *Validator) Run(ctx context.Context, clusterDetails ClusterTarget) ([]Finding, error) {
+	v.init()
+	if !v.gatewaysLoaded {
+		gatewayListResponse, err := v.ListGateways(ctx)
+		if err != nil {
+			return nil, err
+		}
+		v.LoadGateways(ctx, gatewayListResponse.GetClusters())
+	}
+
+	var findings []Finding
+
+	if v.Verbose {
+		if len(clusterDetails.CertificateAuthorityFile) > 0 {
+			fmt.Fprintln(v.Out, "Certificate authority file:", clusterDetails.CertificateAuthorityFile)
+		}
+		fmt.Fprintln(v.Out, "Certificate authority data:", base64.StdEncoding.EncodeToString(clusterDetails.CertificateAuthorityData))
+		fmt.Fprintln(v.Out, "Kubernetes Cluster Endpoint Url:", clusterDetails.Server)
+	}
+
+	// The cluster OIDC discovery document and JWKS are only fetched once a check needs them
+	lookups := &clusterLookups{validator: v, cluster: clusterDetails}
+
+	// loop through all the auth configs and compare the normalized K8SHost property with the retrieved cluster endpoint of clusterDetails.Server
+	matcher := newHostMatcher(v, clusterDetails.Server)
+	for _, gatewayKubeAuthConfig := range v.gatewayKubeAuthConfigs {
+		for _, kubeAuthConfig := range gatewayKubeAuthConfig.KubeAuthConfigs.K8SAuths {
+			matches, reason := matcher.match(ctx, kubeAuthConfig.K8SHost)
+			if !matches {
+				if len(reason) > 0 {
+					fmt.Fprintln(v.Out)
+					fmt.Fprintln(v.Out, "Rejected K8S Auth Config pointing to the same host:", aurora.BrightYellow(GatewayDisplayName(gatewayKubeAuthConfig)+"/"+kubeAuthConfig.Name), kubeAuthConfig.K8SHost)
+					fmt.Fprintln(v.Out, "Rejected because:", aurora.BrightYellow(reason))
+				}
+				continue
+			}
+
+			finding := Finding{
+				GatewayName:    GatewayDisplayName(gatewayKubeAuthConfig),
+				GatewayUrl:     gatewayKubeAuthConfig.GwClusterIdentity.GetClusterUrl(),
+				KubeAuthConfig: kubeAuthConfig,
+			}
+
+			fmt.Fprintln(v.Out)
+			gatewayClusterName := gatewayKubeAuthConfig.GwClusterIdentity.GetClusterName()
+			gatewayClusterDisplayName := gatewayKubeAuthConfig.GwClusterIdentity.GetDisplayName()
+			fmt.Fprintln(v.Out, "Found matching K8S Auth Config for Gateway Cluster:", aurora.BrightGreen(gatewayClusterName))
+			if len(gatewayClusterDisplayName) > 0 {
+				fmt.Fprintln(v.Out, "Gateway Cluster Display Name:", aurora.BrightGreen(gatewayClusterDisplayName))
+			}
+			fmt.Fprintln(v.Out, "Found matching K8S Auth Config for kubernetes cluster:", aurora.BrightGreen(kubeAuthConfig.K8SHost))
+			fmt.Fprintln(v.Out, "K8S Auth Config Name:", aurora.BrightGreen(kubeAuthConfig.Name))
+			fmt.Fprintln(v.Out, "K8S Auth Config Access ID:", aurora.BrightGreen(kubeAuthConfig.AuthMethodAccessID))
+
+			for _, check := range v.Checks {
+				result := runCheck(ctx, check, CheckTarget{
+					Finding:   finding,
+					Cluster:   clusterDetails,
+					Out:       v.Out,
+					validator: v,
+					lookups:   lookups,
+				})
+				if len(result.Status) == 0 {
+					continue
+				}
+				if len(result.Remediation) > 0 {
+					fmt.Fprintln(v.Out, "Remediation for", result.Name+":")
+					fmt.Fprintln(v.Out, aurora.BrightCyan(result.Remediation))
+				}
+				finding.Checks = append(finding.Checks, result)
+			}
+
+			findings = append(findings, finding)
+		}
+	}
+
+	return findings, nil
+}
+
+// validateCaCertificates compares the cluster CA bundle and the k8s auth config CA bundle certificate
+// by certificate and reports any certificate the gateway is missing or has in excess
+func (v *Validator) validateCaCertificates(ctx context.Context, target CheckTarget) CheckResult {
+	caComparison, err := compareCaCertificates(target.Cluster.CertificateAuthorityData, target.KubeAuthConfig.K8SCaCert)
+	if err != nil {
+		fmt.Fprintln(v.Out, "K8S Auth Config CA Cert could NOT be compared:", aurora.BrightRed(err))
+		return newCheckResult(CHECK_CA_CERT, CHECK_STATUS_FAIL, "CA Cert could not be compared: "+err.Error(), nil)
+	}
+
+	for _, certificate := range caComparison.Missing {
+		fmt.Fprintln(v.Out, "K8S Auth Config CA Cert is missing cluster certificate:", aurora.BrightRed(describeCertificate(certificate)))
+	}
+	for _, certificate := range caComparison.Extra {
+		fmt.Fprintln(v.Out, "K8S Auth Config CA Cert has extra certificate not in the cluster CA:", aurora.BrightYellow(describeCertificate(certificate)))
+	}
+	if v.Verbose {
+		for _, certificate := range caComparison.Matching {
+			fmt.Fprintln(v.Out, "K8S Auth Config CA Cert has matching certificate:", describeCertificate(certificate))
+		}
+	}
+
+	caEvidence := map[string]string{
+		"matching_fingerprints": certificateFingerprints(caComparison.Matching),
+		"missing_fingerprints":  certificateFingerprints(caComparison.Missing),
+		"extra_fingerprints":    certificateFingerprints(caComparison.Extra),
+	}
+
+	switch {
+	case !caComparison.Matches():
+		fmt.Fprintln(v.Out, "K8S Auth Config CA Cert does NOT match the cluster CA:", aurora.BrightRed(fmt.Sprintf("%d missing, %d extra certificate(s)", len(caComparison.Missing), len(caComparison.Extra))))
+		return newCheckResult(CHECK_CA_CERT, CHECK_STATUS_FAIL, fmt.Sprintf("CA Cert does not match: %d missing, %d extra certificate(s)", len(caComparison.Missing), len(caComparison.Extra)), caEvidence)
+	case len(caComparison.Extra) > 0:
+		fmt.Fprintln(v.Out, "K8S Auth Config CA Cert matches the cluster CA:", aurora.BrightYellow(fmt.Sprintf("CA Cert matches with %d extra certificate(s)", len(caComparison.Extra))))
+		return newCheckResult(CHECK_CA_CERT, CHECK_STATUS_WARN, fmt.Sprintf("CA Cert matches with %d extra certificate(s)", len(caComparison.Extra)), caEvidence)
+	default:
+		fmt.Fprintln(v.Out, "K8S Auth Config CA Cert matches the cluster CA:", aurora.BrightGreen("CA Cert matches"))
+		return newCheckResult(CHECK_CA_CERT, CHECK_STATUS_PASS, "CA Cert matches", caEvidence)
+	}
+}
+
+// certificateFingerprints returns the comma separated SHA-256 fingerprints of the certificates
+func certificateFingerprints(certificates []*x509.Certificate) string {
+	fingerprints := make([]string, 0, len(certificates))
+	for _, certificate := range certificates {
+		fingerprints = append(fingerprints, certificateFingerprint(certificate))
+	}
+	return strings.Join(fingerprints, ",")
+}
+
+// servingCertificateEvidence describes the API server serving certificate, if one was presented
+func servingCertificateEvidence(servingCertificate *x509.Certificate) map[string]string {
+	if servingCertificate == nil {
+		return nil
+	}
+	return map[string]string{
+		"subject":   servingCertificate.Subject.String(),
+		"issuer":    servingCertificate.Issuer.String(),
+		"dns_names": strings.Join(servingCertificate.DNSNames, ","),
+		"not_after": servingCertificate.NotAfter.UTC().Format(time.RFC3339),
+	}
+}
+
+// validateReviewerJWT decodes the token reviewer JWT offline and prints its claims without ever
+// printing the raw token
+func (v *Validator) validateReviewerJWT(ctx context.Context, target CheckTarget) CheckResult {
+	kubeAuthConfig := target.KubeAuthConfig
+	if len(kubeAuthConfig.K8STokenReviewerJwt) == 0 {
+		fmt.Fprintln(v.Out, "Token Reviewer JWT is not set:", aurora.BrightYellow("the gateway will use the JWT of the workload logging in"))
+		return newCheckResult(CHECK_REVIEWER_JWT, CHECK_STATUS_WARN, "Token Reviewer JWT is not set, the JWT of the workload logging in is used for the TokenReview", nil)
+	}
+
+	claims, err := decodeJWTClaims(kubeAuthConfig.K8STokenReviewerJwt)
+	if err != nil {
+		fmt.Fprintln(v.Out, "Token Reviewer JWT could NOT be decoded:", aurora.BrightRed(err))
+		return newCheckResult(CHECK_REVIEWER_JWT, CHECK_STATUS_FAIL, "Token Reviewer JWT could not be decoded: "+err.Error(), nil)
+	}
+
+	namespace, serviceAccount := claims.ServiceAccount()
+	fmt.Fprintln(v.Out, "Token Reviewer JWT Issuer:", aurora.BrightGreen(claims.Issuer))
+	fmt.Fprintln(v.Out, "Token Reviewer JWT Subject:", aurora.BrightGreen(claims.Subject))
+	fmt.Fprintln(v.Out, "Token Reviewer JWT Service Account:", aurora.BrightGreen(namespace+"/"+serviceAccount))
+	if len(claims.Audiences) > 0 {
+		fmt.Fprintln(v.Out, "Token Reviewer JWT Audiences:", aurora.BrightGreen(strings.Join(claims.Audiences, ",")))
+	}
+	fmt.Fprintln(v.Out, "Token Reviewer JWT Type:", aurora.BrightGreen(claims.TokenType()))
+	if claims.IssuedAt > 0 {
+		fmt.Fprintln(v.Out, "Token Reviewer JWT Issued At:", aurora.BrightGreen(time.Unix(claims.IssuedAt, 0).Format(time.RFC3339)))
+	}
+	if expiresAt := claims.ExpiresAt(); !expiresAt.IsZero() {
+		fmt.Fprintln(v.Out, "Token Reviewer JWT Expires At:", aurora.BrightGreen(expiresAt.Format(time.RFC3339)))
+	}
+
+	status, message := lintReviewerJWT(claims, time.Now(), v.ReviewerExpiryWarning)
+	switch status {
+	case CHECK_STATUS_PASS:
+		fmt.Fprintln(v.Out, "Token Reviewer JWT lint:", aurora.BrightGreen(message))
+	case CHECK_STATUS_WARN:
+		fmt.Fprintln(v.Out, "Token Reviewer JWT lint:", aurora.BrightYellow(message))
+	default:
+		fmt.Fprintln(v.Out, "Token Reviewer JWT lint:", aurora.BrightRed(message))
+	}
+	jwtEvidence := map[string]string{
+		"issuer":          claims.Issuer,
+		"subject":         claims.Subject,
+		"service_account": namespace + "/" + serviceAccount,
+		"audiences":       strings.Join(claims.Audiences, ","),
+		"token_type":      claims.TokenType(),
+	}
+	if expiresAt := claims.ExpiresAt(); !expiresAt.IsZero() {
+		jwtEvidence["expires_at"] = expiresAt.UTC().Format(time.RFC3339)
+	}
+	return newCheckResult(CHECK_REVIEWER_JWT, status, message, jwtEvidence)
+}
+
+// lookupOIDCDiscovery fetches the cluster OIDC discovery document with the kubeconfig credentials
+func (v *Validator) lookupOIDCDiscovery(ctx context.Context, clusterDetails ClusterTarget) (OIDCDiscovery, error) {
+	if clusterDetails.RestConfig == nil {
+		return OIDCDiscovery{}, fmt.Errorf("no kubernetes credentials available")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, v.Timeout)
+	defer cancel()
+
+	return fetchOIDCDiscovery(ctx, clusterDetails.RestConfig)
+}
+
+// validateIssuer compares the k8s auth config issuer and issuer validation setting with the issuer
+// advertised in the cluster OIDC discovery document
+func (v *Validator) validateIssuer(ctx context.Context, target CheckTarget) CheckResult {
+	kubeAuthConfig := target.KubeAuthConfig
+	if v.Verbose {
+		fmt.Fprintln(v.Out, "K8S Auth Config Issuer:", kubeAuthConfig.K8SIssuer)
+		fmt.Fprintln(v.Out, "K8S Auth Config Disable Issuer Validation:", kubeAuthConfig.DisableIssValidation)
+	}
+
+	oidcDiscovery, oidcDiscoveryErr := target.lookups.lookupOIDCDiscovery(ctx)
+	if oidcDiscoveryErr != nil {
+		fmt.Fprintln(v.Out, "K8S Issuer could NOT be compared with the cluster OIDC discovery document:", aurora.BrightYellow(oidcDiscoveryErr))
+		return newCheckResult(CHECK_ISSUER, CHECK_STATUS_WARN, "K8S issuer could not be compared with the cluster OIDC discovery document: "+oidcDiscoveryErr.Error(), nil)
+	}
+
+	fmt.Fprintln(v.Out, "Cluster Service Account Issuer:", aurora.BrightGreen(oidcDiscovery.Issuer))
+	status, message := compareIssuer(kubeAuthConfig, oidcDiscovery.Issuer)
+	switch status {
+	case CHECK_STATUS_PASS:
+		fmt.Fprintln(v.Out, "K8S Issuer:", aurora.BrightGreen(message))
+	case CHECK_STATUS_WARN:
+		fmt.Fprintln(v.Out, "K8S Issuer:", aurora.BrightYellow(message))
+	default:
+		fmt.Fprintln(v.Out, "K8S Issuer:", aurora.BrightRed(message))
+	}
+	return newCheckResult(CHECK_ISSUER, status, message, map[string]string{
+		"cluster_issuer":         oidcDiscovery.Issuer,
+		"k8s_issuer":             kubeAuthConfig.K8SIssuer,
+		"disable_iss_validation": fmt.Sprint(kubeAuthConfig.DisableIssValidation),
+	})
+}
+
+// lookupClusterSigningKeys fetches the cluster service account JWKS with the kubeconfig credentials
+// and converts it into PEM encoded signing keys
+func (v *Validator) lookupClusterSigningKeys(ctx context.Context, clusterDetails ClusterTarget) ([]ServiceAccountSigningKey, error) {
+	if clusterDetails.RestConfig == nil {
+		return nil, fmt.Errorf("no kubernetes credentials available")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, v.Timeout)
+	defer cancel()
+
+	jwks, err := fetchServiceAccountJWKS(ctx, clusterDetails.RestConfig)
+	if err != nil {
+		return nil, err
+	}
+	return signingKeysFromJWKS(jwks)
+}
+
+// describeSigningKey returns the key ID (when known) and fingerprint of the signing key
+func describeSigningKey(signingKey ServiceAccountSigningKey) string {
+	if len(signingKey.KeyID) > 0 {
+		return fmt.Sprintf("kid %s (SHA-256 %s)", signingKey.KeyID, signingKey.Fingerprint)
+	}
+	return fmt.Sprintf("SHA-256 %s", signingKey.Fingerprint)
+}
+
+// describeSigningKeys returns the comma separated descriptions of the signing keys
+func describeSigningKeys(signingKeys []ServiceAccountSigningKey) string {
+	descriptions := make([]string, 0, len(signingKeys))
+	for _, signingKey := range signingKeys {
+		descriptions = append(descriptions, describeSigningKey(signingKey))
+	}
+	return strings.Join(descriptions, ",")
+}
+
+// validatePublicKeys compares the k8s auth config public keys with the cluster service account signing keys.
+// The public keys are only compared when the gateway verifies service account tokens offline.
+func (v *Validator) validatePublicKeys(ctx context.Context, target CheckTarget) CheckResult {
+	kubeAuthConfig := target.KubeAuthConfig
+	if len(kubeAuthConfig.K8SPubKeysPem) == 0 {
+		return CheckResult{}
+	}
+
+	clusterSigningKeys, clusterSigningKeysErr := target.lookups.lookupClusterSigningKeys(ctx)
+	if clusterSigningKeysErr != nil {
+		fmt.Fprintln(v.Out, "K8S Auth Config Public Keys could NOT be compared with the cluster JWKS:", aurora.BrightYellow(clusterSigningKeysErr))
+		return newCheckResult(CHECK_PUB_KEYS, CHECK_STATUS_WARN, "Public keys could not be compared with the cluster JWKS: "+clusterSigningKeysErr.Error(), nil)
+	}
+
+	configKeys, err := parsePublicKeysPem(kubeAuthConfig.K8SPubKeysPem)
+	if err != nil {
+		fmt.Fprintln(v.Out, "K8S Auth Config Public Keys could NOT be parsed:", aurora.BrightRed(err))
+		return newCheckResult(CHECK_PUB_KEYS, CHECK_STATUS_FAIL, "Public keys could not be parsed: "+err.Error(), nil)
+	}
+
+	comparison := comparePublicKeys(clusterSigningKeys, configKeys)
+	for _, signingKey := range comparison.Matching {
+		fmt.Fprintln(v.Out, "K8S Auth Config Public Key matches cluster signing key:", aurora.BrightGreen(describeSigningKey(signingKey)))
+	}
+	for _, signingKey := range comparison.Missing {
+		fmt.Fprintln(v.Out, "K8S Auth Config Public Keys are missing cluster signing key:", aurora.BrightRed(describeSigningKey(signingKey)))
+		if v.Verbose {
+			fmt.Fprint(v.Out, signingKey.PEM)
+		}
+	}
+	for _, signingKey := range comparison.Stale {
+		fmt.Fprintln(v.Out, "K8S Auth Config Public Key is stale, no longer used by the cluster:", aurora.BrightYellow(describeSigningKey(signingKey)))
+	}
+
+	summary := fmt.Sprintf("%d matching, %d missing, %d stale public key(s)", len(comparison.Matching), len(comparison.Missing), len(comparison.Stale))
+	pubKeysEvidence := map[string]string{
+		"matching_keys": describeSigningKeys(comparison.Matching),
+		"missing_keys":  describeSigningKeys(comparison.Missing),
+		"stale_keys":    describeSigningKeys(comparison.Stale),
+	}
+	switch {
+	case len(comparison.Missing) > 0:
+		fmt.Fprintln(v.Out, "K8S Auth Config Public Keys do NOT match the cluster signing keys:", aurora.BrightRed(summary))
+		return newCheckResult(CHECK_PUB_KEYS, CHECK_STATUS_FAIL, "Public keys do not match the cluster signing keys: "+summary, pubKeysEvidence)
+	case len(comparison.Stale) > 0:
+		fmt.Fprintln(v.Out, "K8S Auth Config Public Keys match the cluster signing keys:", aurora.BrightYellow(summary))
+		return newCheckResult(CHECK_PUB_KEYS, CHECK_STATUS_WARN, "Public keys include keys no longer used by the cluster: "+summary, pubKeysEvidence)
+	default:
+		fmt.Fprintln(v.Out, "K8S Auth Config Public Keys match the cluster signing keys:", aurora.BrightGreen(summary))
+		return newCheckResult(CHECK_PUB_KEYS, CHECK_STATUS_PASS, "Public keys match the cluster signing keys: "+summary, pubKeysEvidence)
+	}
+}
+
+// validateApiServerTLS validates the API server certificate chain against the k8s auth config CA certificate
+func (v *Validator) validateApiServerTLS(ctx context.Context, target CheckTarget) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, v.Timeout)
+	defer cancel()
+
+	servingCertificate, err := verifyApiServerTLS(ctx, target.KubeAuthConfig)
+	if err != nil {
+		fmt.Fprintln(v.Out, "Gateway would fail TLS verification against this API server:", aurora.BrightRed(err))
+		return newCheckResult(CHECK_TLS, CHECK_STATUS_FAIL, "Gateway would fail TLS verification against this API server: "+err.Error(), servingCertificateEvidence(servingCertificate))
+	}
+	fmt.Fprintln(v.Out, "API Server TLS certificate is trusted by the K8S Auth Config CA Cert:", aurora.BrightGreen(servingCertificate.Subject.String()))
+	return newCheckResult(CHECK_TLS, CHECK_STATUS_PASS, "API Server TLS certificate is trusted: "+servingCertificate.Subject.String(), servingCertificateEvidence(servingCertificate))
+}
+
+// validateTokenReviewer creates a TokenReview with the token reviewer JWT, just like the gateway does
+func (v *Validator) validateTokenReviewer(ctx context.Context, target CheckTarget) CheckResult {
+	kubeAuthConfig := target.KubeAuthConfig
+	tokenReviewResponse, err := lookupTokenReviewerStatus(k8sHostUrl(kubeAuthConfig)+"/apis/authentication.k8s.io/v1/tokenreviews", kubeAuthConfig, v.Timeout)
+	if err != nil {
+		fmt.Fprintln(v.Out, err)
+	}
+	if !tokenReviewResponse.Status.Authenticated {
+		fmt.Fprintln(v.Out, "Token Reviewer JWT Access is NOT valid for K8S Auth Config:", aurora.BrightRed(kubeAuthConfig.Name))
+		message := "Token Reviewer JWT Access is not valid"
+		if err != nil {
+			message += ": " + err.Error()
+		}
+		return newCheckResult(CHECK_TOKEN_REVIEWER, CHECK_STATUS_FAIL, message, nil)
+	}
+
+	fmt.Fprintln(v.Out, "Token Reviewer JWT Access is valid for user:", aurora.BrightGreen(tokenReviewResponse.Status.User.Username))
+	return newCheckResult(CHECK_TOKEN_REVIEWER, CHECK_STATUS_PASS, "Token Reviewer JWT Access is valid for user: "+tokenReviewResponse.Status.User.Username, map[string]string{
+		"username": tokenReviewResponse.Status.User.Username,
+	})
+}
+
+// validateReviewerRBAC checks that the token reviewer is allowed to create tokenreviews and names the
+// ClusterRoleBinding to system:auth-delegator granting it when the kubeconfig user can list bindings.
+// It only runs once the token-reviewer check has authenticated the token reviewer.
+func (v *Validator) validateReviewerRBAC(ctx context.Context, target CheckTarget) CheckResult {
+	reviewerUsername, authenticated := target.reviewerUsername()
+	if !authenticated {
+		return CheckResult{}
+	}
+	clusterDetails := target.Cluster
+	kubeAuthConfig := target.KubeAuthConfig
+
+	ctx, cancel := context.WithTimeout(ctx, v.Timeout)
+	defer cancel()
+
+	reviewerRestConfig, err := newReviewerRestConfig(kubeAuthConfig, v.Timeout)
+	if err != nil {
+		fmt.Fprintln(v.Out, "Token Reviewer RBAC could NOT be checked:", aurora.BrightRed(err))
+		return newCheckResult(CHECK_REVIEWER_RBAC, CHECK_STATUS_FAIL, "Token Reviewer RBAC could not be checked: "+err.Error(), nil)
+	}
+	reviewerClientset, err := kubernetes.NewForConfig(reviewerRestConfig)
+	if err != nil {
+		fmt.Fprintln(v.Out, "Token Reviewer RBAC could NOT be checked:", aurora.BrightRed(err))
+		return newCheckResult(CHECK_REVIEWER_RBAC, CHECK_STATUS_FAIL, "Token Reviewer RBAC could not be checked: "+err.Error(), nil)
+	}
+
+	allowed, reason, err := canCreateTokenReviews(ctx, reviewerClientset)
+	if err != nil {
+		fmt.Fprintln(v.Out, "Token Reviewer RBAC could NOT be checked:", aurora.BrightRed(err))
+		return newCheckResult(CHECK_REVIEWER_RBAC, CHECK_STATUS_FAIL, "Token Reviewer RBAC could not be checked: "+err.Error(), nil)
+	}
+
+	namespace, serviceAccount := ServiceAccountTokenClaims{Subject: reviewerUsername}.ServiceAccount()
+	if !allowed {
+		message := fmt.Sprintf("Token Reviewer %s cannot create tokenreviews, bind it to the %s ClusterRole", reviewerUsername, AUTH_DELEGATOR_CLUSTER_ROLE)
+		if len(reason) > 0 {
+			message += ": " + reason
+		}
+		fmt.Fprintln(v.Out, "Token Reviewer RBAC is NOT valid:", aurora.BrightRed(message))
+		return newCheckResult(CHECK_REVIEWER_RBAC, CHECK_STATUS_FAIL, message, nil)
+	}
+
+	message := fmt.Sprintf("Token Reviewer %s can create tokenreviews", reviewerUsername)
+
+	// The token reviewer usually cannot list bindings so the kubeconfig credentials are used instead
+	if clusterDetails.RestConfig != nil && len(serviceAccount) > 0 {
+		bindingNames, err := lookupAuthDelegatorBindings(ctx, clusterDetails.RestConfig, namespace, serviceAccount)
+		switch {
+		case err != nil:
+			message += fmt.Sprintf(" (the %s ClusterRoleBinding could not be looked up: %s)", AUTH_DELEGATOR_CLUSTER_ROLE, err)
+		case len(bindingNames) > 0:
+			message += fmt.Sprintf(" through the %s ClusterRoleBinding %s", AUTH_DELEGATOR_CLUSTER_ROLE, strings.Join(bindingNames, ","))
+		default:
+			message += fmt.Sprintf(" but no ClusterRoleBinding to %s was found, the permission is granted by another role", AUTH_DELEGATOR_CLUSTER_ROLE)
+		}
+	}
+
+	fmt.Fprintln(v.Out, "Token Reviewer RBAC is valid:", aurora.BrightGreen(message))
+	return newCheckResult(CHECK_REVIEWER_RBAC, CHECK_STATUS_PASS, message, nil)
+}
+
+// lookupAuthDelegatorBindings lists the system:auth-delegator ClusterRoleBindings of the service account
+// using the kubeconfig credentials
+func lookupAuthDelegatorBindings(ctx context.Context, restConfig *rest.Config, namespace string, serviceAccount string) ([]string, error) {
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	return findAuthDelegatorBindings(ctx, clientset, namespace, serviceAccount)
+}
+
+// validateAuthMethod looks the access ID of the k8s auth config up among the auth methods of the account and
+// checks that it is a Kubernetes auth method whose public key corresponds to the config private key
+func (v *Validator) validateAuthMethod(ctx context.Context, target CheckTarget) CheckResult {
+	if v.Akeyless == nil || len(v.Token) == 0 {
+		return CheckResult{}
+	}
+	kubeAuthConfig := target.KubeAuthConfig
+	accessId := kubeAuthConfig.AuthMethodAccessID
+	if len(accessId) == 0 {
+		fmt.Fprintln(v.Out, "Auth Method is NOT valid:", aurora.BrightRed("the K8S auth config has no auth method access ID"))
+		return newCheckResult(CHECK_AUTH_METHOD, CHECK_STATUS_FAIL, "The K8S auth config has no auth method access ID", nil)
+	}
+
+	authMethods, err := target.lookups.lookupAuthMethods(ctx)
+	if err != nil {
+		fmt.Fprintln(v.Out, "Auth Method could NOT be verified:", aurora.BrightYellow(err))
+		return newCheckResult(CHECK_AUTH_METHOD, CHECK_STATUS_WARN, "Auth method could not be verified: "+err.Error(), nil)
+	}
+	listedAuthMethod, found := authMethods[accessId]
+	if !found {
+		message := fmt.Sprintf("Auth method %s does not exist, it was deleted or the token is not allowed to list it", accessId)
+		fmt.Fprintln(v.Out, "Auth Method is NOT valid:", aurora.BrightRed(message))
+		return newCheckResult(CHECK_AUTH_METHOD, CHECK_STATUS_FAIL, message, nil)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, v.Timeout)
+	defer cancel()
+
+	authMethodName := listedAuthMethod.GetAuthMethodName()
+	authMethod, err := getAuthMethod(ctx, v.Akeyless, v.Token, authMethodName)
+	if err != nil {
+		fmt.Fprintln(v.Out, "Auth Method could NOT be verified:", aurora.BrightYellow(err))
+		return newCheckResult(CHECK_AUTH_METHOD, CHECK_STATUS_WARN, "Auth method could not be verified: "+err.Error(), nil)
+	}
+
+	evidence := map[string]string{
+		"auth_method_name": authMethodName,
+		"auth_method_type": authMethodType(authMethod),
+	}
+	fmt.Fprintln(v.Out, "Auth Method Name:", aurora.BrightGreen(authMethodName))
+	if evidence["auth_method_type"] != ACCESS_TYPE_K8S {
+		message := fmt.Sprintf("Auth method %s is of type %s, not Kubernetes", authMethodName, evidence["auth_method_type"])
+		fmt.Fprintln(v.Out, "Auth Method is NOT valid:", aurora.BrightRed(message))
+		return newCheckResult(CHECK_AUTH_METHOD, CHECK_STATUS_FAIL, message, evidence)
+	}
+
+	accessInfo := authMethod.GetAccessInfo()
+	k8sAccessRules := accessInfo.GetK8sAccessRules()
+	pubKey := k8sAccessRules.GetPubKey()
+	if len(pubKey) == 0 {
+		message := fmt.Sprintf("Auth method %s has no public key to compare with the K8S auth config private key", authMethodName)
+		fmt.Fprintln(v.Out, "Auth Method could NOT be verified:", aurora.BrightYellow(message))
+		return newCheckResult(CHECK_AUTH_METHOD, CHECK_STATUS_WARN, message, evidence)
+	}
+	publicKey, err := parseAuthMethodPublicKey(pubKey)
+	if err != nil {
+		fmt.Fprintln(v.Out, "Auth Method could NOT be verified:", aurora.BrightYellow(err))
+		return newCheckResult(CHECK_AUTH_METHOD, CHECK_STATUS_WARN, "Auth method could not be verified: "+err.Error(), evidence)
+	}
+	evidence["auth_method_pub_key_fingerprint"] = publicKey.Fingerprint
+
+	if len(kubeAuthConfig.AuthMethodPrvKeyPem) == 0 {
+		message := fmt.Sprintf("The gateway did not return the private key of the K8S auth config to compare with the public key of auth method %s", authMethodName)
+		fmt.Fprintln(v.Out, "Auth Method could NOT be verified:", aurora.BrightYellow(message))
+		return newCheckResult(CHECK_AUTH_METHOD, CHECK_STATUS_WARN, message, evidence)
+	}
+	configFingerprint, err := configPublicKeyFingerprint(kubeAuthConfig.AuthMethodPrvKeyPem)
+	if err != nil {
+		fmt.Fprintln(v.Out, "Auth Method is NOT valid:", aurora.BrightRed(err))
+		return newCheckResult(CHECK_AUTH_METHOD, CHECK_STATUS_FAIL, "The K8S auth config private key is not valid: "+err.Error(), evidence)
+	}
+	evidence["config_pub_key_fingerprint"] = configFingerprint
+
+	if configFingerprint != publicKey.Fingerprint {
+		message := fmt.Sprintf("The public key of auth method %s does not correspond to the K8S auth config private key", authMethodName)
+		if configNames := v.configsMatchingPublicKey(target, publicKey); len(configNames) > 0 {
+			message += ", it corresponds to the private key of " + strings.Join(configNames, ",") + " instead"
+			evidence["matching_configs"] = strings.Join(configNames, ",")
+		}
+		fmt.Fprintln(v.Out, "Auth Method is NOT valid:", aurora.BrightRed(message))
+		return newCheckResult(CHECK_AUTH_METHOD, CHECK_STATUS_FAIL, message, evidence)
+	}
+
+	message := fmt.Sprintf("Auth method %s is a Kubernetes auth method whose public key corresponds to the K8S auth config private key", authMethodName)
+	fmt.Fprintln(v.Out, "Auth Method is valid:", aurora.BrightGreen(message))
+	return newCheckResult(CHECK_AUTH_METHOD, CHECK_STATUS_PASS, message, evidence)
+}
+
+// validateE2ELogin mints a short-lived service account token and logs in to Akeyless through the
+// k8s auth config to prove the whole chain works
+func (v *Validator) validateE2ELogin(ctx context.Context, target CheckTarget) CheckResult {
+	if !v.E2E.Enabled {
+		return CheckResult{}
+	}
+	clusterDetails := target.Cluster
+
+	ctx, cancel := context.WithTimeout(ctx, v.Timeout)
+	defer cancel()
+
+	namespace := v.E2E.Namespace
+	if len(namespace) == 0 {
+		namespace = clusterDetails.Namespace
+	}
+	if len(namespace) == 0 {
+		namespace = "default"
+	}
+	serviceAccount := namespace + "/" + v.E2E.ServiceAccount
+
+	if clusterDetails.RestConfig == nil {
+		fmt.Fprintln(v.Out, "E2E login could NOT be tested:", aurora.BrightRed("no kubernetes credentials available to mint a token"))
+		return newCheckResult(CHECK_E2E_LOGIN, CHECK_STATUS_FAIL, "E2E login could not be tested: no kubernetes credentials available to mint a token", nil)
+	}
+	clientset, err := kubernetes.NewForConfig(clusterDetails.RestConfig)
+	if err != nil {
+		fmt.Fprintln(v.Out, "E2E login could NOT be tested:", aurora.BrightRed(err))
+		return newCheckResult(CHECK_E2E_LOGIN, CHECK_STATUS_FAIL, "E2E login could not be tested: "+err.Error(), nil)
+	}
+
+	serviceAccountToken, err := mintServiceAccountToken(ctx, clientset, namespace, v.E2E.ServiceAccount, int64(v.E2E.TokenTTL.Seconds()))
+	if err != nil {
+		fmt.Fprintln(v.Out, "E2E login could NOT be tested:", aurora.BrightRed(err))
+		return newCheckResult(CHECK_E2E_LOGIN, CHECK_STATUS_FAIL, "E2E login could not be tested: "+err.Error(), nil)
+	}
+
+	_, err = loginWithKubernetesAuth(ctx, v.Akeyless, target.GatewayUrl, target.KubeAuthConfig, serviceAccountToken)
+	if err != nil {
+		fmt.Fprintln(v.Out, "E2E login FAILED for service account "+serviceAccount+":", aurora.BrightRed(err))
+		return newCheckResult(CHECK_E2E_LOGIN, CHECK_STATUS_FAIL, "E2E login failed for service account "+serviceAccount+": "+err.Error(), nil)
+	}
+
+	fmt.Fprintln(v.Out, "E2E login succeeded for service account:", aurora.BrightGreen(serviceAccount))
+	return newCheckResult(CHECK_E2E_LOGIN, CHECK_STATUS_PASS, "E2E login succeeded for service account "+serviceAccount, nil)
+}
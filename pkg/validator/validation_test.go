@@ -0,0 +1,144 @@
+package validator
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListContextNames(t *testing.T) {
+	config, err := NewKubeconfigLoadingRules(writeTestKubeconfig(t, "a.yaml", testKubeconfigA)).Load()
+	assert.NoError(t, err)
+	config.Contexts["prod-eks"] = config.Contexts["ctx-a"]
+	config.Contexts["prod-gke"] = config.Contexts["ctx-a"]
+
+	contextNames, err := ListContextNames(config, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ctx-a", "prod-eks", "prod-gke"}, contextNames)
+
+	contextNames, err = ListContextNames(config, "^prod-")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"prod-eks", "prod-gke"}, contextNames)
+
+	_, err = ListContextNames(config, "[")
+	assert.Error(t, err)
+}
+
+func TestValidateClusterTarget(t *testing.T) {
+	apiServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") == "Bearer good-jwt" {
+			w.Write([]byte(`{"status": {"authenticated": true, "user": {"username": "system:serviceaccount:akeyless:reviewer"}}}`))
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"status": {}}`))
+	}))
+	defer apiServer.Close()
+
+	caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: apiServer.Certificate().Raw})
+	kubeAuthConfigs := KubeAuthConfigs{K8SAuths: []KubeAuthConfig{
+		{Name: "good", K8SHost: apiServer.URL, K8SCaCert: base64.StdEncoding.EncodeToString(caData), K8STokenReviewerJwt: "good-jwt"},
+		{Name: "bad", K8SHost: apiServer.URL, K8SCaCert: base64.StdEncoding.EncodeToString(generateTestCertificatePEM(t, "other")), K8STokenReviewerJwt: "bad-jwt"},
+		{Name: "other-cluster", K8SHost: "https://other.example.com"},
+	}}
+
+	gatewayServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/config/k8s-auths", r.URL.Path)
+		assert.Equal(t, "Bearer t-123", r.Header.Get("Authorization"))
+		json.NewEncoder(w).Encode(kubeAuthConfigs)
+	}))
+	defer gatewayServer.Close()
+
+	akeylessServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		assert.Equal(t, "/list-gateways", r.URL.Path)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"clusters": []map[string]string{
+				{"cluster_name": "gw-prod", "cluster_url": gatewayServer.URL, "status": GATEWAY_RUNNING_STATUS},
+				{"cluster_name": "gw-stopped", "cluster_url": "http://127.0.0.1:1", "status": "Stopped"},
+			},
+		})
+	}))
+	defer akeylessServer.Close()
+
+	var output bytes.Buffer
+	v := New(newTestAkeylessClient(akeylessServer.URL), "t-123")
+	v.Out = &output
+
+	findings, err := v.Run(context.Background(), ClusterTarget{
+		ContextName:              "ctx",
+		Server:                   apiServer.URL,
+		CertificateAuthorityData: caData,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, findings, 2)
+	assert.Equal(t, "gw-prod", findings[0].GatewayName)
+	assert.Equal(t, gatewayServer.URL, findings[0].GatewayUrl)
+	assert.Equal(t, CHECK_STATUS_PASS, findings[0].StatusOf(CHECK_CA_CERT))
+	assert.Equal(t, CHECK_STATUS_PASS, findings[0].StatusOf(CHECK_TLS))
+	assert.Equal(t, CHECK_STATUS_PASS, findings[0].StatusOf(CHECK_TOKEN_REVIEWER))
+	assert.Equal(t, CHECK_STATUS_FAIL, findings[1].StatusOf(CHECK_CA_CERT))
+	assert.Equal(t, CHECK_STATUS_FAIL, findings[1].StatusOf(CHECK_TLS))
+	assert.Equal(t, CHECK_STATUS_FAIL, findings[1].StatusOf(CHECK_TOKEN_REVIEWER))
+	assert.Empty(t, findings[0].Checks[0].Remediation)
+	assert.Contains(t, findings[1].Checks[0].Remediation, "--k8s-ca-cert '"+base64.StdEncoding.EncodeToString(caData)+"'")
+	assert.Contains(t, output.String(), "Found matching K8S Auth Config for Gateway Cluster:")
+	assert.Contains(t, output.String(), "Remediation for ca-cert:")
+	assert.NotContains(t, output.String(), "good-jwt")
+	assert.NotContains(t, output.String(), "bad-jwt")
+
+	// the gateways are only fetched once
+	akeylessServer.Close()
+	noMatch, err := v.Run(context.Background(), ClusterTarget{ContextName: "none", Server: "https://unknown.example.com"})
+	assert.NoError(t, err)
+	assert.Empty(t, noMatch)
+}
+
+func TestRunListGatewaysError(t *testing.T) {
+	akeylessServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error": "token expired"}`))
+	}))
+	defer akeylessServer.Close()
+
+	_, err := New(newTestAkeylessClient(akeylessServer.URL), "t-expired").Run(context.Background(), ClusterTarget{Server: "https://cluster.example.com"})
+	assert.ErrorContains(t, err, "token expired")
+}
+
+func TestValidateNormalizedK8SHost(t *testing.T) {
+	apiServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/apis/authentication.k8s.io/v1/tokenreviews" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"status": {}}`))
+			return
+		}
+		w.Write([]byte(`{"status": {"authenticated": true, "user": {"username": "system:serviceaccount:akeyless:reviewer"}}}`))
+	}))
+	defer apiServer.Close()
+
+	caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: apiServer.Certificate().Raw})
+	caCert := base64.StdEncoding.EncodeToString(caData)
+	v := New(nil, "t-123")
+	v.Out = io.Discard
+
+	// both configs match the cluster, the checks must reach the same API server
+	for _, k8sHost := range []string{apiServer.URL + "/", strings.TrimPrefix(apiServer.URL, "https://")} {
+		target := CheckTarget{Finding: Finding{KubeAuthConfig: KubeAuthConfig{Name: "k8s", K8SHost: k8sHost, K8SCaCert: caCert, K8STokenReviewerJwt: "good-jwt"}}}
+
+		tlsResult := v.validateApiServerTLS(context.Background(), target)
+		assert.Equal(t, CHECK_STATUS_PASS, tlsResult.Status, k8sHost+": "+tlsResult.Message)
+		tokenReviewerResult := v.validateTokenReviewer(context.Background(), target)
+		assert.Equal(t, CHECK_STATUS_PASS, tokenReviewerResult.Status, k8sHost+": "+tokenReviewerResult.Message)
+	}
+}
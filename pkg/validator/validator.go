@@ -0,0 +1,128 @@
+// Package validator validates the Akeyless gateway k8s auth configs against kubernetes clusters, checking
+// everything the gateway relies on to authenticate workloads with the k8s auth method.
+package validator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	akeyless "github.com/akeylesslabs/akeyless-go/v2"
+)
+
+const DEFAULT_CONCURRENCY = 4
+const DEFAULT_TIMEOUT = 30 * time.Second
+const DEFAULT_REVIEWER_EXPIRY_WARNING = 7 * 24 * time.Hour
+const DEFAULT_E2E_SERVICE_ACCOUNT = "default"
+const DEFAULT_E2E_TOKEN_TTL = 10 * time.Minute
+
+// E2EOptions configures the end-to-end login made through every matching k8s auth config
+type E2EOptions struct {
+	Enabled bool
+	// Namespace of the service account, defaults to the namespace of the cluster target
+	Namespace      string
+	ServiceAccount string
+	TokenTTL       time.Duration
+}
+
+// Validator holds the clients and settings used to validate clusters. The gateway k8s auth configs are
+// fetched once and reused by every Run.
+type Validator struct {
+	// Akeyless lists the gateways and, for the e2e login, authenticates through the k8s auth configs
+	Akeyless *akeyless.V2ApiService
+	// HTTPClient fetches the k8s auth configs from the gateways
+	HTTPClient HTTPDoer
+	// Token authenticates the calls to the Akeyless API and the gateways
+	Token string
+
+	GatewayNameFilter     string
+	Concurrency           int
+	GatewayTimeout        time.Duration
+	Timeout               time.Duration
+	ReviewerExpiryWarning time.Duration
+	E2E                   E2EOptions
+	// ResolveHosts also matches the configs whose K8S host resolves to the same addresses as the cluster server
+	ResolveHosts bool
+	// Resolver resolves the hostnames compared with ResolveHosts
+	Resolver HostResolver
+	// Checks run in order against every matching k8s auth config, defaults to the built-in checks
+	Checks []Check
+
+	// Out receives the human readable progress of the validation, nothing is printed when it is nil
+	Out     io.Writer
+	Verbose bool
+
+	gatewayKubeAuthConfigs []GatewayKubeAuthConfigs
+	gatewaysLoaded         bool
+}
+
+// New returns a validator using the Akeyless client and token with the default settings
+func New(client *akeyless.V2ApiService, token string) *Validator {
+	return &Validator{
+		Akeyless:              client,
+		HTTPClient:            http.DefaultClient,
+		Resolver:              net.DefaultResolver,
+		Token:                 token,
+		Concurrency:           DEFAULT_CONCURRENCY,
+		GatewayTimeout:        DEFAULT_TIMEOUT,
+		Timeout:               DEFAULT_TIMEOUT,
+		ReviewerExpiryWarning: DEFAULT_REVIEWER_EXPIRY_WARNING,
+		E2E: E2EOptions{
+			ServiceAccount: DEFAULT_E2E_SERVICE_ACCOUNT,
+			TokenTTL:       DEFAULT_E2E_TOKEN_TTL,
+		},
+		Checks: BuiltinChecks(),
+	}
+}
+
+// init falls back to the defaults for the settings left unset on a validator that was not created with New
+func (v *Validator) init() {
+	if v.HTTPClient == nil {
+		v.HTTPClient = http.DefaultClient
+	}
+	if v.Resolver == nil {
+		v.Resolver = net.DefaultResolver
+	}
+	if v.Out == nil {
+		v.Out = io.Discard
+	}
+	if v.Concurrency < 1 {
+		v.Concurrency = DEFAULT_CONCURRENCY
+	}
+	if v.GatewayTimeout <= 0 {
+		v.GatewayTimeout = DEFAULT_TIMEOUT
+	}
+	if v.Timeout <= 0 {
+		v.Timeout = DEFAULT_TIMEOUT
+	}
+	if len(v.E2E.ServiceAccount) == 0 {
+		v.E2E.ServiceAccount = DEFAULT_E2E_SERVICE_ACCOUNT
+	}
+	if v.E2E.TokenTTL <= 0 {
+		v.E2E.TokenTTL = DEFAULT_E2E_TOKEN_TTL
+	}
+	if v.Checks == nil {
+		v.Checks = BuiltinChecks()
+	}
+}
+
+// ListGateways lists the gateways of the account with the Akeyless client
+func (v *Validator) ListGateways(ctx context.Context) (akeyless.GatewaysListResponse, error) {
+	if v.Akeyless == nil {
+		return akeyless.GatewaysListResponse{}, errors.New("no Akeyless client is configured to list the gateways")
+	}
+	if len(v.Token) == 0 {
+		return akeyless.GatewaysListResponse{}, errors.New("Akeyless token is not set")
+	}
+
+	token := v.Token
+	gatewayListResponse, _, err := v.Akeyless.ListGateways(ctx).Body(akeyless.ListGateways{Token: &token}).Execute()
+	if err != nil {
+		return gatewayListResponse, fmt.Errorf("unable to list the gateways: %s", DescribeAkeylessError(err))
+	}
+	return gatewayListResponse, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/akeyless-community/k8s-auth-validator/pkg/validator"
+)
+
+// REPORT_SCHEMA_VERSION must be bumped whenever a field of the structured report is renamed or removed
+const REPORT_SCHEMA_VERSION = "v1"
+
+const OUTPUT_TEXT = "text"
+const OUTPUT_JSON = "json"
+
+// Report is the structured document emitted by the structured output modes
+type Report struct {
+	SchemaVersion string                    `json:"schema_version"`
+	ToolVersion   string                    `json:"tool_version"`
+	GeneratedAt   time.Time                 `json:"generated_at"`
+	Gateways      []validator.GatewayReport `json:"gateways"`
+	Clusters      []ClusterReport           `json:"clusters"`
+	// Inventory lists every k8s auth config of every gateway, only with --inventory
+	Inventory []validator.InventoryEntry `json:"inventory,omitempty"`
+}
+
+// ClusterReport describes a validated cluster and every k8s auth config matching it
+type ClusterReport struct {
+	Context        string         `json:"context"`
+	ContextSource  string         `json:"context_source,omitempty"`
+	Cluster        string         `json:"cluster"`
+	Server         string         `json:"server"`
+	Namespace      string         `json:"namespace,omitempty"`
+	User           string         `json:"user,omitempty"`
+	MatchedConfigs []ConfigReport `json:"matched_configs"`
+	// NearMisses are the configs pointing to the same host as the cluster that were rejected, and why
+	NearMisses []validator.NearMiss `json:"near_misses,omitempty"`
+}
+
+// ConfigReport describes a matched k8s auth config and the result of every check run against it
+type ConfigReport struct {
+	Gateway    string                  `json:"gateway"`
+	GatewayUrl string                  `json:"gateway_url,omitempty"`
+	Name       string                  `json:"name"`
+	K8sHost    string                  `json:"k8s_host"`
+	AccessId   string                  `json:"access_id"`
+	Checks     []validator.CheckResult `json:"checks"`
+}
+
+// buildReport converts the gateway reports and cluster validations into the structured report
+func buildReport(gatewayReports []validator.GatewayReport, clusterValidations []ClusterValidation) Report {
+	report := Report{
+		SchemaVersion: REPORT_SCHEMA_VERSION,
+		ToolVersion:   version,
+		GeneratedAt:   time.Now().UTC(),
+		Gateways:      gatewayReports,
+		Clusters:      make([]ClusterReport, 0, len(clusterValidations)),
+	}
+	if report.Gateways == nil {
+		report.Gateways = []validator.GatewayReport{}
+	}
+
+	for _, clusterValidation := range clusterValidations {
+		clusterReport := ClusterReport{
+			Context:        clusterValidation.Target.ContextName,
+			ContextSource:  clusterValidation.Target.ContextSource,
+			Cluster:        clusterValidation.Target.ClusterName,
+			Server:         clusterValidation.Target.Server,
+			Namespace:      clusterValidation.Target.Namespace,
+			User:           clusterValidation.Target.AuthInfo,
+			MatchedConfigs: make([]ConfigReport, 0, len(clusterValidation.Findings)),
+			NearMisses:     clusterValidation.NearMisses,
+		}
+
+		for _, finding := range clusterValidation.Findings {
+			checks := finding.Checks
+			if checks == nil {
+				checks = []validator.CheckResult{}
+			}
+			clusterReport.MatchedConfigs = append(clusterReport.MatchedConfigs, ConfigReport{
+				Gateway:    finding.GatewayName,
+				GatewayUrl: finding.GatewayUrl,
+				Name:       finding.KubeAuthConfig.Name,
+				K8sHost:    finding.KubeAuthConfig.K8SHost,
+				AccessId:   finding.KubeAuthConfig.AuthMethodAccessID,
+				Checks:     checks,
+			})
+		}
+
+		report.Clusters = append(report.Clusters, clusterReport)
+	}
+
+	return report
+}
+
+// writeJSONReport writes the report as an indented JSON document
+func writeJSONReport(writer io.Writer, report Report) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("unable to write the JSON report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/akeyless-community/k8s-auth-validator/pkg/validator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildReport(t *testing.T) {
+	finding := validator.Finding{
+		GatewayName: "gw-prod",
+		GatewayUrl:  "https://gw.example.com",
+		KubeAuthConfig: validator.KubeAuthConfig{
+			Name:                "k8s-conf",
+			K8SHost:             "https://cluster.example.com",
+			AuthMethodAccessID:  "p-1234",
+			K8STokenReviewerJwt: "secret-jwt",
+		},
+		Checks: []validator.CheckResult{
+			{Name: validator.CHECK_CA_CERT, Status: validator.CHECK_STATUS_FAIL, Message: "CA Cert does not match", Evidence: map[string]string{"missing_fingerprints": "ab:cd"}, Remediation: "akeyless gateway-update-k8s-auth-config"},
+			{Name: validator.CHECK_TOKEN_REVIEWER, Status: validator.CHECK_STATUS_PASS, Message: "Token Reviewer JWT Access is valid"},
+		},
+	}
+
+	clusterValidations := []ClusterValidation{
+		{
+			Target:   validator.ClusterTarget{ContextName: "ctx-a", ClusterName: "cluster-a", Server: "https://cluster.example.com"},
+			Findings: []validator.Finding{finding},
+		},
+		{
+			Target:     validator.ClusterTarget{ContextName: "ctx-b", ClusterName: "cluster-b", Server: "https://other.example.com"},
+			NearMisses: []validator.NearMiss{{GatewayName: "gw-prod", Name: "k8s-other", K8sHost: "http://other.example.com", Reason: "same hostname as the cluster server but scheme http instead of https"}},
+		},
+	}
+
+	report := buildReport(nil, clusterValidations)
+	assert.Equal(t, REPORT_SCHEMA_VERSION, report.SchemaVersion)
+	assert.Empty(t, report.Gateways)
+	assert.Len(t, report.Clusters, 2)
+	assert.Equal(t, "p-1234", report.Clusters[0].MatchedConfigs[0].AccessId)
+	assert.Empty(t, report.Clusters[1].MatchedConfigs)
+
+	var buffer bytes.Buffer
+	assert.NoError(t, writeJSONReport(&buffer, report))
+	assert.NotContains(t, buffer.String(), "secret-jwt")
+
+	var decoded map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &decoded))
+	assert.Equal(t, "v1", decoded["schema_version"])
+	assert.Equal(t, []interface{}{}, decoded["gateways"])
+
+	clusters := decoded["clusters"].([]interface{})
+	assert.Equal(t, []interface{}{}, clusters[1].(map[string]interface{})["matched_configs"])
+	assert.NotContains(t, clusters[0], "near_misses")
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"gateway":  "gw-prod",
+		"name":     "k8s-other",
+		"k8s_host": "http://other.example.com",
+		"reason":   "same hostname as the cluster server but scheme http instead of https",
+	}}, clusters[1].(map[string]interface{})["near_misses"])
+
+	matchedConfig := clusters[0].(map[string]interface{})["matched_configs"].([]interface{})[0].(map[string]interface{})
+	checks := matchedConfig["checks"].([]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"name":        "ca-cert",
+		"status":      "FAIL",
+		"message":     "CA Cert does not match",
+		"evidence":    map[string]interface{}{"missing_fingerprints": "ab:cd"},
+		"remediation": "akeyless gateway-update-k8s-auth-config",
+	}, checks[0])
+	assert.NotContains(t, checks[1], "evidence")
+	assert.NotContains(t, checks[1], "remediation")
+}
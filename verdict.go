@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/akeyless-community/k8s-auth-validator/pkg/validator"
+	"github.com/logrusorgru/aurora/v4"
+)
+
+// ClusterValidation holds the findings of every matching k8s auth config for a cluster
+type ClusterValidation struct {
+	Target   validator.ClusterTarget
+	Findings []validator.Finding
+	// NearMisses are the configs pointing to the same host as the cluster that were rejected
+	NearMisses []validator.NearMiss
+}
+
+// summarizeCheck returns the combined verdict of the named check across all the matching configs of a cluster
+func summarizeCheck(clusterValidation ClusterValidation, name string) aurora.Value {
+	passed := 0
+	warned := 0
+	ran := 0
+	for _, finding := range clusterValidation.Findings {
+		switch finding.StatusOf(name) {
+		case validator.CHECK_STATUS_PASS:
+			passed++
+			ran++
+		case validator.CHECK_STATUS_WARN:
+			warned++
+			ran++
+		case validator.CHECK_STATUS_FAIL:
+			ran++
+		}
+	}
+
+	switch {
+	case ran == 0:
+		return aurora.BrightYellow("n/a")
+	case passed == ran:
+		return aurora.BrightGreen(validator.CHECK_STATUS_PASS)
+	case passed+warned == ran:
+		return aurora.BrightYellow(validator.CHECK_STATUS_WARN)
+	case passed+warned == 0:
+		return aurora.BrightRed(validator.CHECK_STATUS_FAIL)
+	default:
+		return aurora.BrightYellow(fmt.Sprintf("%d/%d %s", passed+warned, ran, validator.CHECK_STATUS_PASS))
+	}
+}
+
+// verdictTableChecks are the checks summarized as columns of the verdict table
+var verdictTableChecks = []string{validator.CHECK_CA_CERT, validator.CHECK_TLS, validator.CHECK_REVIEWER_JWT, validator.CHECK_ISSUER, validator.CHECK_PUB_KEYS, validator.CHECK_TOKEN_REVIEWER, validator.CHECK_REVIEWER_RBAC, validator.CHECK_AUTH_METHOD, validator.CHECK_E2E_LOGIN}
+
+// printVerdictTable prints one line per validated cluster summarizing the matching configs and check results
+func printVerdictTable(clusterValidations []ClusterValidation) {
+	fmt.Fprintln(out)
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	header := []string{"CONTEXT", "SERVER", "MATCHING K8S AUTH CONFIGS"}
+	for _, checkName := range verdictTableChecks {
+		header = append(header, strings.ToUpper(checkName))
+	}
+	fmt.Fprintln(writer, strings.Join(header, "\t"))
+
+	for _, clusterValidation := range clusterValidations {
+		matchingConfigs := aurora.BrightRed("none")
+		if len(clusterValidation.Findings) > 0 {
+			configNames := make([]string, 0, len(clusterValidation.Findings))
+			for _, finding := range clusterValidation.Findings {
+				configNames = append(configNames, finding.GatewayName+"/"+finding.KubeAuthConfig.Name)
+			}
+			matchingConfigs = aurora.BrightGreen(strings.Join(configNames, ","))
+		}
+
+		row := []string{clusterValidation.Target.ContextName, clusterValidation.Target.Server, matchingConfigs.String()}
+		for _, checkName := range verdictTableChecks {
+			row = append(row, summarizeCheck(clusterValidation, checkName).String())
+		}
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+
+	writer.Flush()
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/akeyless-community/k8s-auth-validator/pkg/validator"
+	akeyless "github.com/akeylesslabs/akeyless-go/v2"
+	"github.com/logrusorgru/aurora/v4"
+)
+
+// WATCH_POLL_INTERVAL is how often the kubeconfig files are checked for changes in watch mode
+const WATCH_POLL_INTERVAL = 2 * time.Second
+
+// watchKey identifies a check of a matching k8s auth config of a cluster, the host-match check of a
+// cluster has no config
+type watchKey struct {
+	Context string
+	Config  string
+	Check   string
+}
+
+func (k watchKey) String() string {
+	if len(k.Config) == 0 {
+		return k.Check + " for " + k.Context
+	}
+	return k.Check + " for " + k.Config + " on " + k.Context
+}
+
+// watchState holds the result of every check of the last validation run
+type watchState map[watchKey]validator.CheckResult
+
+// newWatchState indexes the check results of the validated clusters
+func newWatchState(clusterValidations []ClusterValidation) watchState {
+	state := watchState{}
+	for _, clusterValidation := range clusterValidations {
+		contextName := clusterValidation.Target.ContextName
+		hostMatch := validator.CheckResult{Name: CHECK_HOST_MATCH, Status: validator.CHECK_STATUS_PASS, Message: "K8S host matches " + clusterValidation.Target.Server}
+		if len(clusterValidation.Findings) == 0 {
+			hostMatch.Status = validator.CHECK_STATUS_FAIL
+			hostMatch.Message = "Unable to find any existing gateway k8s auth config with this kubernetes host endpoint: " + clusterValidation.Target.Server
+		}
+		state[watchKey{Context: contextName, Check: CHECK_HOST_MATCH}] = hostMatch
+
+		for _, finding := range clusterValidation.Findings {
+			configName := finding.GatewayName + "/" + finding.KubeAuthConfig.Name
+			for _, check := range finding.Checks {
+				state[watchKey{Context: contextName, Config: configName, Check: check.Name}] = check
+			}
+		}
+	}
+	return state
+}
+
+// sortedWatchKeys returns the keys of the state sorted by context, config and check
+func sortedWatchKeys(state watchState) []watchKey {
+	keys := make([]watchKey, 0, len(state))
+	for key := range state {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Context != keys[j].Context {
+			return keys[i].Context < keys[j].Context
+		}
+		if keys[i].Config != keys[j].Config {
+			return keys[i].Config < keys[j].Config
+		}
+		return keys[i].Check < keys[j].Check
+	})
+	return keys
+}
+
+// watchTransitions describes the checks whose status changed between two runs. Without a previous run
+// the checks that do not pass are described.
+func watchTransitions(previous watchState, current watchState) []aurora.Value {
+	var transitions []aurora.Value
+
+	for _, key := range sortedWatchKeys(current) {
+		check := current[key]
+		if previous == nil {
+			switch check.Status {
+			case validator.CHECK_STATUS_FAIL:
+				transitions = append(transitions, aurora.BrightRed(fmt.Sprintf("%s is failing: %s", key, check.Message)))
+			case validator.CHECK_STATUS_WARN:
+				transitions = append(transitions, aurora.BrightYellow(fmt.Sprintf("%s is warning: %s", key, check.Message)))
+			}
+			continue
+		}
+
+		previousCheck, existed := previous[key]
+		if existed && previousCheck.Status == check.Status {
+			continue
+		}
+		switch {
+		case check.Status == validator.CHECK_STATUS_FAIL:
+			transitions = append(transitions, aurora.BrightRed(fmt.Sprintf("%s started failing: %s", key, check.Message)))
+		case check.Status == validator.CHECK_STATUS_WARN:
+			transitions = append(transitions, aurora.BrightYellow(fmt.Sprintf("%s started warning: %s", key, check.Message)))
+		case existed:
+			transitions = append(transitions, aurora.BrightGreen(fmt.Sprintf("%s recovered: %s", key, check.Message)))
+		default:
+			transitions = append(transitions, aurora.BrightGreen(fmt.Sprintf("%s started passing: %s", key, check.Message)))
+		}
+	}
+
+	for _, key := range sortedWatchKeys(previous) {
+		if _, exists := current[key]; !exists {
+			transitions = append(transitions, aurora.BrightCyan(fmt.Sprintf("%s is no longer checked", key)))
+		}
+	}
+
+	return transitions
+}
+
+// watchedFiles returns the files whose changes trigger a new validation run, the kubeconfig files or the
+// service account CA certificate when running in the cluster
+func watchedFiles() []string {
+	if options.InCluster {
+		return []string{filepath.Join(validator.SERVICE_ACCOUNT_PATH, "ca.crt")}
+	}
+	loadingRules := validator.NewKubeconfigLoadingRules(options.Kubeconfig)
+	if len(loadingRules.ExplicitPath) > 0 {
+		return []string{loadingRules.ExplicitPath}
+	}
+	return loadingRules.GetLoadingPrecedence()
+}
+
+// fileModTimes returns the modification time of every file, files that cannot be read have a zero time
+func fileModTimes(paths []string) map[string]time.Time {
+	modTimes := make(map[string]time.Time, len(paths))
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			modTimes[path] = info.ModTime()
+		} else {
+			modTimes[path] = time.Time{}
+		}
+	}
+	return modTimes
+}
+
+// modTimesChanged reports whether any of the files was modified, created or removed
+func modTimesChanged(previous map[string]time.Time, current map[string]time.Time) bool {
+	for path, modTime := range current {
+		if !previous[path].Equal(modTime) {
+			return true
+		}
+	}
+	return len(previous) != len(current)
+}
+
+// reloadClusterTargets resolves the clusters to validate again without printing, so that kubeconfig
+// changes are picked up in watch mode
+func reloadClusterTargets(validateManyContexts bool) ([]validator.ClusterTarget, error) {
+	if options.InCluster {
+		clusterDetails, err := validator.ResolveInClusterTarget()
+		if err != nil {
+			return nil, err
+		}
+		return []validator.ClusterTarget{clusterDetails}, nil
+	}
+
+	config, err := validator.NewKubeconfigLoadingRules(options.Kubeconfig).Load()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the kubeconfig: %w", err)
+	}
+
+	if !validateManyContexts {
+		clusterDetails, err := validator.ResolveClusterTarget(config, options.Context)
+		if err != nil {
+			return nil, err
+		}
+		return []validator.ClusterTarget{clusterDetails}, nil
+	}
+
+	contextNames, err := validator.ListContextNames(config, options.ContextRegex)
+	if err != nil {
+		return nil, err
+	}
+	var clusterTargets []validator.ClusterTarget
+	for _, contextName := range contextNames {
+		// contexts that cannot be resolved are skipped, like on the first run
+		if clusterTarget, err := validator.ResolveClusterTarget(config, contextName); err == nil {
+			clusterTargets = append(clusterTargets, clusterTarget)
+		}
+	}
+	return clusterTargets, nil
+}
+
+// watchValidation runs one validation in watch mode, fetching the gateway k8s auth configs again so that
+// changes on the gateways are picked up too
+func watchValidation(ctx context.Context, client *akeyless.V2ApiService, k8sAuthValidator *validator.Validator, validateManyContexts bool) ([]validator.GatewayReport, []ClusterValidation, error) {
+	// tokens retrieved with an access ID expire, so a fresh one is retrieved for every run
+	if len(options.AccessId) > 0 {
+		authBody, err := newAuthBody(options)
+		if err != nil {
+			return nil, nil, err
+		}
+		token, err := authenticate(ctx, client, authBody)
+		if err != nil {
+			return nil, nil, err
+		}
+		k8sAuthValidator.Token = token
+	}
+
+	clusterTargets, err := reloadClusterTargets(validateManyContexts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gatewayListResponse, err := k8sAuthValidator.ListGateways(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	gatewayReports := k8sAuthValidator.LoadGateways(ctx, gatewayListResponse.GetClusters())
+
+	clusterValidations := make([]ClusterValidation, 0, len(clusterTargets))
+	for _, clusterDetails := range clusterTargets {
+		findings, err := k8sAuthValidator.Run(ctx, clusterDetails)
+		if err != nil {
+			return nil, nil, err
+		}
+		clusterValidations = append(clusterValidations, ClusterValidation{
+			Target:   clusterDetails,
+			Findings: findings,
+		})
+	}
+	return gatewayReports, clusterValidations, nil
+}
+
+// watch validates the clusters every interval and whenever the watched files change until the context is
+// done, printing only the checks whose status changed. The metrics, when set, are updated after every run.
+func watch(ctx context.Context, client *akeyless.V2ApiService, k8sAuthValidator *validator.Validator, validateManyContexts bool, metrics *validationMetrics) {
+	// the detailed output of every run is replaced by the transitions
+	k8sAuthValidator.Out = io.Discard
+
+	fmt.Fprintln(out, "Watch Flag Set:", aurora.BrightCyan(options.WatchInterval))
+
+	files := watchedFiles()
+	modTimes := fileModTimes(files)
+
+	var previous watchState
+	runValidation := func(reason string) {
+		runTime := time.Now()
+		now := runTime.Format(time.RFC3339)
+		gatewayReports, clusterValidations, err := watchValidation(ctx, client, k8sAuthValidator, validateManyContexts)
+		if err != nil {
+			fmt.Fprintln(out, now, aurora.BrightRed("Validation failed, retrying on the next run:"), err)
+			return
+		}
+		if metrics != nil {
+			metrics.update(gatewayReports, clusterValidations, runTime)
+		}
+
+		current := newWatchState(clusterValidations)
+		transitions := watchTransitions(previous, current)
+		if previous == nil {
+			fmt.Fprintln(out, now, "Watching", aurora.BrightGreen(len(clusterValidations)), "cluster(s),", aurora.BrightGreen(len(current)), "check(s)")
+		} else if options.Verbose {
+			fmt.Fprintln(out, now, "Validated after", reason+",", aurora.BrightGreen(len(transitions)), "transition(s)")
+		}
+		for _, transition := range transitions {
+			fmt.Fprintln(out, now, transition)
+		}
+		previous = current
+	}
+
+	runValidation("start")
+
+	intervalTicker := time.NewTicker(options.WatchInterval)
+	defer intervalTicker.Stop()
+	pollTicker := time.NewTicker(WATCH_POLL_INTERVAL)
+	defer pollTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(out, aurora.BrightCyan("Stopped watching"))
+			return
+		case <-intervalTicker.C:
+			runValidation("the interval elapsed")
+		case <-pollTicker.C:
+			currentModTimes := fileModTimes(files)
+			if !modTimesChanged(modTimes, currentModTimes) {
+				continue
+			}
+			modTimes = currentModTimes
+			fmt.Fprintln(out, time.Now().Format(time.RFC3339), "Kubeconfig changed, validating again")
+			runValidation("the kubeconfig changed")
+			intervalTicker.Reset(options.WatchInterval)
+		}
+	}
+}
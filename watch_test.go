@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/akeyless-community/k8s-auth-validator/pkg/validator"
+	"github.com/logrusorgru/aurora/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClusterValidation(tokenReviewerStatus validator.CheckStatus) ClusterValidation {
+	return ClusterValidation{
+		Target: validator.ClusterTarget{ContextName: "prod-eks", Server: "https://cluster.example.com"},
+		Findings: []validator.Finding{{
+			GatewayName:    "gw-prod",
+			KubeAuthConfig: validator.KubeAuthConfig{Name: "k8s-eks"},
+			Checks: []validator.CheckResult{
+				{Name: validator.CHECK_CA_CERT, Status: validator.CHECK_STATUS_PASS, Message: "CA Cert matches"},
+				{Name: validator.CHECK_TOKEN_REVIEWER, Status: tokenReviewerStatus, Message: "Token Reviewer JWT Access"},
+			},
+		}},
+	}
+}
+
+func transitionTexts(transitions []aurora.Value) []string {
+	texts := make([]string, 0, len(transitions))
+	for _, transition := range transitions {
+		texts = append(texts, transition.Value().(string))
+	}
+	return texts
+}
+
+func TestWatchTransitions(t *testing.T) {
+	passing := newWatchState([]ClusterValidation{newTestClusterValidation(validator.CHECK_STATUS_PASS)})
+	failing := newWatchState([]ClusterValidation{newTestClusterValidation(validator.CHECK_STATUS_FAIL)})
+	assert.Len(t, passing, 3)
+
+	// the first run only reports what does not pass
+	assert.Empty(t, watchTransitions(nil, passing))
+	assert.Equal(t, []string{"token-reviewer for gw-prod/k8s-eks on prod-eks is failing: Token Reviewer JWT Access"}, transitionTexts(watchTransitions(nil, failing)))
+
+	assert.Empty(t, watchTransitions(passing, passing))
+	assert.Equal(t, []string{"token-reviewer for gw-prod/k8s-eks on prod-eks started failing: Token Reviewer JWT Access"}, transitionTexts(watchTransitions(passing, failing)))
+	assert.Equal(t, []string{"token-reviewer for gw-prod/k8s-eks on prod-eks recovered: Token Reviewer JWT Access"}, transitionTexts(watchTransitions(failing, passing)))
+
+	noMatch := newWatchState([]ClusterValidation{{Target: validator.ClusterTarget{ContextName: "prod-eks", Server: "https://cluster.example.com"}}})
+	assert.Equal(t, []string{
+		"host-match for prod-eks started failing: Unable to find any existing gateway k8s auth config with this kubernetes host endpoint: https://cluster.example.com",
+		"ca-cert for gw-prod/k8s-eks on prod-eks is no longer checked",
+		"token-reviewer for gw-prod/k8s-eks on prod-eks is no longer checked",
+	}, transitionTexts(watchTransitions(passing, noMatch)))
+}
+
+func TestFileModTimes(t *testing.T) {
+	kubeconfigPath := filepath.Join(t.TempDir(), "config")
+	missingPath := filepath.Join(t.TempDir(), "missing")
+
+	before := fileModTimes([]string{kubeconfigPath, missingPath})
+	assert.True(t, before[kubeconfigPath].IsZero())
+	assert.False(t, modTimesChanged(before, fileModTimes([]string{kubeconfigPath, missingPath})))
+
+	assert.NoError(t, os.WriteFile(kubeconfigPath, []byte("apiVersion: v1"), 0600))
+	created := fileModTimes([]string{kubeconfigPath, missingPath})
+	assert.True(t, modTimesChanged(before, created))
+
+	assert.NoError(t, os.Chtimes(kubeconfigPath, time.Now(), created[kubeconfigPath].Add(time.Minute)))
+	assert.True(t, modTimesChanged(created, fileModTimes([]string{kubeconfigPath, missingPath})))
+}